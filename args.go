@@ -2,14 +2,245 @@ package main
 
 import (
 	"embed"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 )
 
 //go:embed version.txt
 var VERSION embed.FS
 
+// errShowHelp is returned by parseArgs when the help flag is given.
+var errShowHelp = errors.New("help requested")
+
+// errShowVersion is returned by parseArgs when the version flag is given.
+var errShowVersion = errors.New("version requested")
+
+// flagDef describes a command-line option. The help text, the parser and the shell completions are
+// all generated from these definitions.
+type flagDef struct {
+	// short name, without the leading dash, or empty if there is none
+	short string
+	// long name, without the leading dashes
+	long string
+	// name of the value, shown in the help text, or empty for flags that take no value
+	value string
+	// valid values, shown in the help text and used for validation
+	choices []string
+	// whether the flag may be given more than once
+	repeat bool
+	// description, shown in the help text; may span several lines
+	usage string
+	// applies the flag to the options, with its value if it takes one
+	apply func(opts *Options, value string) error
+	// turns off a flag that takes no value, with `--no-NAME` or a false value in the configuration;
+	// nil if it can not be turned off
+	unset func(opts *Options)
+	// clears the values of a repeated flag, so the command line replaces the configured ones
+	reset func(opts *Options)
+}
+
+// commandDef describes a subcommand of the program.
+type commandDef struct {
+	// name of the command
+	name string
+	// positional arguments of the command, shown in the help text
+	args string
+	// description, shown in the help text
+	usage string
+	// returns the flags of the command
+	flags func() []flagDef
+	// valid values of the positional arguments, or nil if they are files
+	choices []string
+	// runs the command with the arguments following its name, and returns the exit code
+	run func(args []string) int
+}
+
+// commands lists the subcommands of the program. It is populated in init, as some commands refer to it.
+var commands []commandDef
+
+func init() {
+	commands = []commandDef{
+		{"diff", "OLD NEW", "Show the differences between two trees", func() []flagDef { return diffFlags(nil) }, nil, runDiff},
+		{"check", "TREE DIR", "Compare a tree with a directory", checkFlags, nil, runCheck},
+		{"docs", "PATH...", "Update rendered trees in Markdown files", func() []flagDef { return docsFlags(nil) }, nil, runDocs},
+		{"completion", "SHELL", "Print a shell completion script", completionFlags, completionShells, runCompletion},
+		{"browse", "TREE", "Browse a tree interactively", browseFlags, nil, runBrowse},
+		{"man", "", "Print the man page", manFlags, nil, runMan},
+	}
+}
+
+// commonFlags lists the flags shared by the program and all of its commands.
+var commonFlags = []flagDef{
+	{short: "h", long: "help", usage: "Show this help message and exit", apply: func(opts *Options, value string) error {
+		return errShowHelp
+	}},
+	{short: "V", long: "version", usage: "Show the version number and exit", apply: func(opts *Options, value string) error {
+		return errShowVersion
+	}},
+	{long: "no-config", usage: "Ignore the " + CONFIG_FILE + " file and " + ENV_PREFIX + "* environment variables", apply: func(opts *Options, value string) error {
+		return nil
+	}},
+}
+
+// inputFlags lists the flags that select the input of the program.
+var inputFlags = []flagDef{
+	{short: "f", long: "file", value: "FILE", repeat: true, usage: "Read from FILE\nMay be repeated, to display each file under its name", apply: func(opts *Options, value string) error {
+		opts.fromFiles = append(opts.fromFiles, value)
+		return nil
+	}, reset: func(opts *Options) {
+		opts.fromFiles = []string{}
+	}},
+	{long: "merge", usage: "Merge the trees of several files at the root, instead of under their names", apply: func(opts *Options, value string) error {
+		opts.mergeFiles = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.mergeFiles = false
+	}},
+	{long: "watch", usage: "Render again whenever an input file changes, until interrupted", apply: func(opts *Options, value string) error {
+		opts.watch = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.watch = false
+	}},
+	{short: "-", long: "stdin", usage: "Read from stdin", apply: func(opts *Options, value string) error {
+		opts.fromStdin = true
+		return nil
+	}},
+}
+
+// outputFlags lists the flags that select the output of the program.
+var outputFlags = []flagDef{
+	{short: "o", long: "out", value: "FILE", usage: "Write the output to FILE, replacing it only once rendering succeeds", apply: func(opts *Options, value string) error {
+		opts.outFile = value
+		return nil
+	}},
+	{long: "skip-unchanged", usage: "With --out, do not write FILE if its contents would not change", apply: func(opts *Options, value string) error {
+		opts.skipUnchanged = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.skipUnchanged = false
+	}},
+}
+
+// optionFlags lists the flags that change how a tree is transformed and displayed.
+var optionFlags = []flagDef{
+	{short: "c", long: "charset", value: "CHARSET", choices: []string{"utf-8", "ascii"}, usage: "Use CHARSET to display characters", apply: func(opts *Options, value string) error {
+		opts.charset = value
+		return nil
+	}},
+	{short: "s", long: "trailing-slash", usage: "Display trailing slash on directory", apply: func(opts *Options, value string) error {
+		opts.trailingSlash = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.trailingSlash = false
+	}},
+	{short: "p", long: "full-path", usage: "Display full path", apply: func(opts *Options, value string) error {
+		opts.fullPath = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.fullPath = false
+	}},
+	{short: "r", long: "root-path", value: "PATH", usage: "Use PATH to change the name of the root node (default: .)\nN/A if `--no-root-dot` is enabled", apply: func(opts *Options, value string) error {
+		opts.rootPath = value
+		return nil
+	}},
+	{short: "D", long: "no-root-dot", usage: "Do not display a root element", apply: func(opts *Options, value string) error {
+		opts.rootDot = false
+		return nil
+	}, unset: func(opts *Options) {
+		opts.rootDot = true
+	}},
+	{short: "a", long: "attributes", usage: "Display node attributes, e.g. `main.go {owner=@web}`", apply: func(opts *Options, value string) error {
+		opts.attributes = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.attributes = false
+	}},
+	{short: "S", long: "summary", usage: "Display directory and file counts after the tree", apply: func(opts *Options, value string) error {
+		opts.summary = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.summary = false
+		opts.summaryDepths = false
+	}},
+	{long: "summary-depths", usage: "Like --summary, also display counts per depth and the max depth", apply: func(opts *Options, value string) error {
+		opts.summary = true
+		opts.summaryDepths = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.summaryDepths = false
+	}},
+	{long: "sort", value: "MODE", choices: sortModes, usage: "Sort children by MODE, instead of keeping the input order", apply: func(opts *Options, value string) error {
+		opts.sortBy = value
+		return nil
+	}},
+	{long: "dirs-first", usage: "Display directories before files", apply: func(opts *Options, value string) error {
+		opts.dirsFirst = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.dirsFirst = false
+	}},
+	{long: "ignore-case", usage: "Ignore case when sorting", apply: func(opts *Options, value string) error {
+		opts.ignoreCase = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.ignoreCase = false
+	}},
+	{short: "L", long: "max-depth", value: "N", usage: "Display at most N levels below the root, eliding deeper branches", apply: func(opts *Options, value string) error {
+		maxDepth, err := strconv.Atoi(value)
+		if err != nil || maxDepth < 1 {
+			return fmt.Errorf("invalid max depth: %s (expected 1 or more)", value)
+		}
+		opts.maxDepth = maxDepth
+		return nil
+	}},
+	{short: "P", long: "include", value: "PATTERN", repeat: true, usage: "Display only nodes matching PATTERN, and their ancestors", apply: func(opts *Options, value string) error {
+		pattern, err := parsePattern(value)
+		if err != nil {
+			return err
+		}
+		opts.includes = append(opts.includes, pattern)
+		return nil
+	}, reset: func(opts *Options) {
+		opts.includes = []*Pattern{}
+	}},
+	{short: "X", long: "exclude", value: "PATTERN", repeat: true, usage: "Do not display nodes matching PATTERN\nPATTERN is a glob, `re:REGEX` or `attr:KEY[=VALUE]`, and may be repeated", apply: func(opts *Options, value string) error {
+		pattern, err := parsePattern(value)
+		if err != nil {
+			return err
+		}
+		opts.excludes = append(opts.excludes, pattern)
+		return nil
+	}, reset: func(opts *Options) {
+		opts.excludes = []*Pattern{}
+	}},
+	{long: "collapse", usage: "Merge chains of single-directory nodes into one line, e.g. `src/main/java`", apply: func(opts *Options, value string) error {
+		opts.collapse = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.collapse = false
+	}},
+	{long: "merge-duplicates", usage: "Merge sibling nodes with the same name, combining their children", apply: func(opts *Options, value string) error {
+		opts.mergeDupes = true
+		return nil
+	}, unset: func(opts *Options) {
+		opts.mergeDupes = false
+	}},
+	{long: "empty-names", value: "MODE", choices: emptyNameModes, usage: "Handle lines with only whitespace by MODE\n`skip` ignores them, `placeholder` displays them as `" + EMPTY_NAME_PLACEHOLDER + "`,\nand `error` stops with an error", apply: func(opts *Options, value string) error {
+		opts.emptyNames = value
+		return nil
+	}},
+}
+
+// mainFlags returns the flags of the program when it is run without a command.
+func mainFlags() []flagDef {
+	return slices.Concat(commonFlags, inputFlags, outputFlags, optionFlags)
+}
+
 // helpText generates and returns a strings.Builder containing the help text for the program.
 // The help text includes usage instructions and descriptions of the available command-line options.
 //
@@ -21,106 +252,285 @@ func helpText() strings.Builder {
 	var builder strings.Builder
 	builder.WriteString("Usage: treelike [OPTIONS] [TREE-STRUCTURE]" + LE)
 	builder.WriteString("Prints a tree-like representation of the input." + LE)
+	builder.WriteString("TREE-STRUCTURE is read as the lines of the tree, not as files; use -f to read files." + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Commands:" + LE)
+	for _, command := range commands {
+		builder.WriteString(formatHelpLine("  "+command.name+" "+command.args, command.usage+", see `treelike "+command.name+" --help`"))
+	}
 	builder.WriteString("" + LE)
 	builder.WriteString("Options:" + LE)
-	builder.WriteString("  -h, --help               Show this help message and exit" + LE)
-	builder.WriteString("  -V, --version            Show the version number and exit" + LE)
-	builder.WriteString("  -f, --file FILE          Read from FILE" + LE)
-	builder.WriteString("   -, --stdin              Read from stdin" + LE)
-	builder.WriteString("  -c, --charset CHARSET    Use CHARSET to display characters (utf-8, ascii)" + LE)
-	builder.WriteString("  -s, --trailing-slash     Display trailing slash on directory" + LE)
-	builder.WriteString("  -p, --full-path          Display full path" + LE)
-	builder.WriteString("  -r, --root-path          Use PATH to change the name of the root node (default: .)" + LE)
-	builder.WriteString("                           N/A if `--no-root-dot` is enabled" + LE)
-	builder.WriteString("  -D, --no-root-dot        Do not display a root element" + LE)
+	builder.WriteString(flagsHelpText(mainFlags()))
+	builder.WriteString("" + LE)
+	builder.WriteString("Options that take no value can be turned off with `--no-` before their name, e.g. to" + LE)
+	builder.WriteString("override the configuration, or with `--root-dot` for `--no-root-dot`." + LE)
 	return builder
 
 }
 
-// getOpts parses command-line arguments and returns an Options struct populated with the parsed values.
-// It supports various flags to customize the behavior of the program, such as reading from stdin,
-// specifying a file, setting the charset, and more. If an invalid charset is provided, the function
-// prints an error message and exits the program.
+// flagsHelpText generates the help text lines for the given flags, one or more lines per flag.
+//
+// Parameters:
+//
+//	flags - The flags to describe.
+//
+// Returns:
+//
+//	string - The help text lines.
+func flagsHelpText(flags []flagDef) string {
+	var builder strings.Builder
+	for _, flag := range flags {
+		names := "    "
+		if flag.short != "" {
+			names = fmt.Sprintf("%4s,", "-"+strings.TrimPrefix(flag.short, "-"))
+		} else {
+			names += " "
+		}
+		names += " --" + flag.long
+		if flag.value != "" {
+			names += " " + flag.value
+		}
+		usage := flag.usage
+		if len(flag.choices) > 0 {
+			first, rest, _ := strings.Cut(usage, "\n")
+			usage = first + " (" + strings.Join(flag.choices, ", ") + ")"
+			if rest != "" {
+				usage += "\n" + rest
+			}
+		}
+		builder.WriteString(formatHelpLine(names, usage))
+	}
+	return builder.String()
+}
+
+// formatHelpLine formats a help text entry, with its usage aligned to the usage column. Each line of a
+// multi-line usage is aligned on its own line.
+//
+// Parameters:
+//
+//	names - The names of the entry, such as `  -f, --file FILE`.
+//	usage - The description of the entry.
+//
+// Returns:
+//
+//	string - The formatted lines.
+func formatHelpLine(names string, usage string) string {
+	LE := getLE()
+	const column = 27
+	var builder strings.Builder
+	for i, line := range strings.Split(usage, "\n") {
+		prefix := ""
+		if i == 0 {
+			prefix = names
+		}
+		if len(prefix) >= column {
+			builder.WriteString(prefix + LE)
+			prefix = ""
+		}
+		builder.WriteString(prefix + strings.Repeat(" ", column-len(prefix)) + line + LE)
+	}
+	return builder.String()
+}
+
+// getOpts parses the given command-line arguments and returns an Options struct populated with the parsed
+// values. Positional arguments are collected as the tree structure to describe. If the help or version flag
+// is given, the function prints the help text or version and exits the program. If the arguments are invalid,
+// it prints an error message and the help text, and exits the program.
 //
-// Supported flags:
+// See mainFlags for the supported flags.
 //
-//	-h, --help            : Display help text and exit.
-//	-, --stdin            : Read input from stdin.
-//	-f, --file <filename> : Read input from the specified file.
-//	-c, --charset <name>  : Set the charset (valid values are "utf-8" and "ascii").
-//	-s, --trailing-slash  : Enable trailing slash in output.
-//	-p, --full-path       : Enable full path in output.
-//	-D, --no-root-dot     : Disable the root dot in output.
+// Parameters:
+//
+//	args - The command-line arguments, without the program name.
 //
 // Returns:
 //
 //	Options - A struct containing the parsed options.
-func getOpts() *Options {
-	opts := DefaultOptions()
-	args := os.Args[1:]
-	for len(args) > 0 {
-		switch args[0] {
-		case "-h", "--help":
-			{
-				help := helpText()
-				fmt.Printf("%s", help.String())
-				os.Exit(0)
+func getOpts(args []string) *Options {
+	opts, err := configuredOptions(args, optionFlags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "treelike: %s\n", err)
+		os.Exit(2)
+	}
+	positional, err := parseArgs(opts, args, mainFlags())
+	if err != nil {
+		handleArgsError(err, helpText())
+	}
+	for _, arg := range positional {
+		opts.extra.WriteString(arg + "\n")
+	}
+	return opts
+}
+
+// handleArgsError prints the help text, version or error returned by parseArgs and exits the program.
+// Help and version requests exit with 0, and invalid arguments print the error and help text to stderr,
+// and exit with 2.
+//
+// Parameters:
+//
+//	err - The error returned by parseArgs.
+//	help - The help text of the command.
+func handleArgsError(err error, help strings.Builder) {
+	switch {
+	case errors.Is(err, errShowHelp):
+		fmt.Print(help.String())
+		os.Exit(0)
+	case errors.Is(err, errShowVersion):
+		version, err := VERSION.ReadFile("version.txt")
+		if err != nil {
+			fmt.Println("Error getting version:", err)
+			os.Exit(0)
+		}
+		fmt.Println(string(version))
+		os.Exit(0)
+	}
+	fmt.Fprintf(os.Stderr, "treelike: %s\n\n%s", err, help.String())
+	os.Exit(2)
+}
+
+// parseArgs parses command-line arguments according to the given flag definitions, in the style of
+// POSIX and GNU tools:
+//
+//	--file FILE, --file=FILE : long flags, with their value as the next argument or after `=`
+//	-f FILE, -fFILE          : short flags, with their value as the next argument or attached
+//	-sp                      : combined short flags
+//	-                        : stdin, if a flag with the short name `-` is defined, otherwise positional
+//	--no-summary             : turns off a flag that takes no value, e.g. one set by the configuration
+//	--                       : ends the flags, every argument after it is positional
+//
+// The first value of a repeated flag replaces the values set by the configuration, and the next ones are
+// added to it.
+//
+// Parameters:
+//
+//	opts - The options to apply the flags to.
+//	args - The command-line arguments to parse.
+//	flags - The flags to accept.
+//
+// Returns:
+//
+//	[]string - The positional arguments, in order.
+//	error - An error object describing the first invalid argument, errShowHelp or errShowVersion,
+//	        otherwise nil.
+func parseArgs(opts *Options, args []string, flags []flagDef) ([]string, error) {
+	var positional []string
+	repeated := map[string]bool{}
+	apply := func(flag *flagDef, value string) error {
+		if flag.reset != nil && !repeated[flag.long] {
+			flag.reset(opts)
+			repeated[flag.long] = true
+		}
+		return applyFlag(flag, opts, value)
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			positional = append(positional, args[i+1:]...)
+			return positional, nil
+		case arg == "-":
+			flag := findFlag(flags, "-", true)
+			if flag == nil {
+				positional = append(positional, arg)
+				continue
 			}
-		case "-V", "--version":
-			{
-				version, err := VERSION.ReadFile("version.txt")
-				if err != nil {
-					fmt.Println("Error getting version:", err)
-					os.Exit(0)
+			if err := flag.apply(opts, ""); err != nil {
+				return positional, err
+			}
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			flag := findFlag(flags, name, false)
+			if negated := findNegatedFlag(flags, name); flag == nil && negated != nil {
+				if hasValue {
+					return positional, fmt.Errorf("option --%s does not take a value", name)
 				}
-				fmt.Println(string(version))
-				os.Exit(0)
+				negated.unset(opts)
+				continue
 			}
-		case "-", "--stdin":
-			{
-				opts.fromStdin = true
-				args = args[1:]
+			if flag == nil {
+				return positional, fmt.Errorf("unknown option: --%s", name)
 			}
-		case "-f", "--file":
-			{
-				opts.fromFile = args[1]
-				args = args[2:]
+			if flag.value == "" && hasValue {
+				return positional, fmt.Errorf("option --%s does not take a value", name)
 			}
-		case "-c", "--charset":
-			{
-				opts.charset = args[1]
-				if opts.charset != "utf-8" && opts.charset != "ascii" {
-					fmt.Fprintf(os.Stderr, "Invalid charset: %s\n", opts.charset)
-					os.Exit(1)
+			if flag.value != "" && !hasValue {
+				if i+1 >= len(args) {
+					return positional, fmt.Errorf("option --%s requires a value", name)
 				}
-				args = args[2:]
-			}
-		case "-s", "--trailing-slash":
-			{
-				opts.trailingSlash = true
-				args = args[1:]
-			}
-		case "-p", "--full-path":
-			{
-				opts.fullPath = true
-				args = args[1:]
+				i++
+				value = args[i]
 			}
-		case "-D", "--no-root-dot":
-			{
-				opts.rootDot = false
-				args = args[1:]
+			if err := apply(flag, value); err != nil {
+				return positional, err
 			}
-		case "-r", "--root-path":
-			{
-				opts.rootPath = args[1]
-				args = args[2:]
+		case strings.HasPrefix(arg, "-"):
+			for j := 1; j < len(arg); j++ {
+				name := string(arg[j])
+				flag := findFlag(flags, name, true)
+				if flag == nil {
+					return positional, fmt.Errorf("unknown option: -%s", name)
+				}
+				value := ""
+				if flag.value != "" {
+					if j+1 < len(arg) {
+						value = arg[j+1:]
+					} else if i+1 < len(args) {
+						i++
+						value = args[i]
+					} else {
+						return positional, fmt.Errorf("option -%s requires a value", name)
+					}
+				}
+				if err := apply(flag, value); err != nil {
+					return positional, err
+				}
+				if flag.value != "" {
+					break
+				}
 			}
 		default:
-			{
-				opts.extra.WriteString(args[0] + "\n")
-				args = args[1:]
-			}
+			positional = append(positional, arg)
 		}
 	}
-	return opts
+
+	return positional, nil
+}
+
+// findFlag returns the flag with the given short or long name, or nil if there is none.
+func findFlag(flags []flagDef, name string, short bool) *flagDef {
+	for i, flag := range flags {
+		if (short && flag.short == name) || (!short && flag.long == name) {
+			return &flags[i]
+		}
+	}
+	return nil
+}
+
+// findNegatedFlag returns the flag that `--NAME` turns off, or nil if there is none. Flags are turned off
+// with `--no-` before their name, or without it for flags whose name starts with `no-`, such as
+// `--root-dot` for `--no-root-dot`.
+func findNegatedFlag(flags []flagDef, name string) *flagDef {
+	for i, flag := range flags {
+		if flag.unset != nil && negatedName(flag.long) == name {
+			return &flags[i]
+		}
+	}
+	return nil
+}
+
+// negatedName returns the long name that turns off a flag, such as `no-summary` for `summary`.
+func negatedName(long string) string {
+	if name, ok := strings.CutPrefix(long, "no-"); ok {
+		return name
+	}
+	return "no-" + long
+}
+
+// applyFlag validates the value of a flag against its choices, and applies it to the options.
+func applyFlag(flag *flagDef, opts *Options, value string) error {
+	if len(flag.choices) > 0 && !slices.Contains(flag.choices, value) {
+		return fmt.Errorf("invalid value for --%s: %s (valid values: %s)", flag.long, value, strings.Join(flag.choices, ", "))
+	}
+	return flag.apply(opts, value)
 }
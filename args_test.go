@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args       []string
+		check      func(opts *Options) bool
+		positional []string
+	}{
+		{[]string{"-f", "tree.txt"}, func(opts *Options) bool { return slices.Equal(opts.fromFiles, []string{"tree.txt"}) }, nil},
+		{[]string{"--file=tree.txt"}, func(opts *Options) bool { return slices.Equal(opts.fromFiles, []string{"tree.txt"}) }, nil},
+		{[]string{"-ftree.txt"}, func(opts *Options) bool { return slices.Equal(opts.fromFiles, []string{"tree.txt"}) }, nil},
+		{[]string{"-f", "a.txt", "--file", "b.txt", "--merge"}, func(opts *Options) bool {
+			return slices.Equal(opts.fromFiles, []string{"a.txt", "b.txt"}) && opts.mergeFiles
+		}, nil},
+		{[]string{"-sp"}, func(opts *Options) bool { return opts.trailingSlash && opts.fullPath }, nil},
+		{[]string{"-spc", "ascii"}, func(opts *Options) bool { return opts.trailingSlash && opts.fullPath && opts.charset == "ascii" }, nil},
+		{[]string{"-L2", "--sort", "natural"}, func(opts *Options) bool { return opts.maxDepth == 2 && opts.sortBy == "natural" }, nil},
+		{[]string{"-"}, func(opts *Options) bool { return opts.fromStdin }, nil},
+		{[]string{"a", "-D", "b"}, func(opts *Options) bool { return !opts.rootDot }, []string{"a", "b"}},
+		{[]string{"--", "-D", "--file"}, func(opts *Options) bool { return opts.rootDot }, []string{"-D", "--file"}},
+		{[]string{"-S", "--no-summary"}, func(opts *Options) bool { return !opts.summary && !opts.summaryDepths }, nil},
+		{[]string{"-D", "--root-dot", "--no-collapse"}, func(opts *Options) bool { return opts.rootDot && !opts.collapse }, nil},
+		{[]string{"-P", "*.go", "-X", "vendor"}, func(opts *Options) bool { return len(opts.includes) == 1 && len(opts.excludes) == 1 }, nil},
+	}
+
+	for _, test := range tests {
+		opts := DefaultOptions()
+		positional, err := parseArgs(opts, test.args, mainFlags())
+		if err != nil {
+			t.Errorf("parseArgs(%q) failed: %v", test.args, err)
+			continue
+		}
+		if !test.check(opts) {
+			t.Errorf("parseArgs(%q) did not set the expected options", test.args)
+		}
+		if strings.Join(positional, ",") != strings.Join(test.positional, ",") {
+			t.Errorf("parseArgs(%q) positional\n actual = %q\nwant   = %q", test.args, positional, test.positional)
+		}
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-f"}, "option -f requires a value"},
+		{[]string{"--file"}, "option --file requires a value"},
+		{[]string{"--bogus"}, "unknown option: --bogus"},
+		{[]string{"-sz"}, "unknown option: -z"},
+		{[]string{"-I", "*.go"}, "unknown option: -I"},
+		{[]string{"--summary=yes"}, "option --summary does not take a value"},
+		{[]string{"--no-summary=yes"}, "option --no-summary does not take a value"},
+		{[]string{"--no-charset"}, "unknown option: --no-charset"},
+		{[]string{"--no-no-root-dot"}, "unknown option: --no-no-root-dot"},
+		{[]string{"-c", "latin1"}, "invalid value for --charset: latin1 (valid values: utf-8, ascii)"},
+		{[]string{"-L", "-1"}, "invalid max depth: -1 (expected 1 or more)"},
+		{[]string{"-L", "0"}, "invalid max depth: 0 (expected 1 or more)"},
+		{[]string{"--max-depth=two"}, "invalid max depth: two (expected 1 or more)"},
+	}
+
+	for _, test := range tests {
+		_, err := parseArgs(DefaultOptions(), test.args, mainFlags())
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("parseArgs(%q) error\n actual = %v\nwant   = %q", test.args, err, test.expected)
+		}
+	}
+
+	if _, err := parseArgs(DefaultOptions(), []string{"-sh"}, mainFlags()); !errors.Is(err, errShowHelp) {
+		t.Errorf("parseArgs(-sh) expected errShowHelp, got %v", err)
+	}
+}
@@ -0,0 +1,615 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// browser is the state of the interactive tree browser. It is independent of the terminal: keys are
+// given to handleKey, and the screen is built by lines, so it can be tested without one.
+type browser struct {
+	// root node of the tree, which is not displayed as a row
+	root *Node
+	// formatting options of the rows
+	opts *Options
+	// every node below the root, in tree order, to search through
+	all []*Node
+	// index of each node in all
+	order map[*Node]int
+	// nodes whose children are displayed
+	expanded map[*Node]bool
+	// displayed nodes, in tree order
+	rows []*Node
+	// index of the selected row
+	cursor int
+	// index of the first row on the screen
+	offset int
+	// size of the screen, in columns and lines
+	width, height int
+	// whether a search query is being typed
+	searching bool
+	// current or last search query
+	query string
+	// selected node and expanded nodes when the search started, restored if it is cancelled
+	searchFrom     *Node
+	searchExpanded map[*Node]bool
+	// message displayed in the status line until the next key
+	message string
+	// text to copy to the clipboard, taken by the terminal loop
+	yank string
+	// whether the browser should exit
+	quit bool
+}
+
+// browseFlags returns the flags of the browse command.
+func browseFlags() []flagDef {
+	return slices.Concat(commonFlags, optionFlags)
+}
+
+// browseHelpText generates and returns a strings.Builder containing the help text for the browse command.
+//
+// Returns:
+//
+//	strings.Builder - A builder containing the formatted help text.
+func browseHelpText() strings.Builder {
+	LE := getLE()
+	var builder strings.Builder
+	builder.WriteString("Usage: treelike browse [OPTIONS] TREE" + LE)
+	builder.WriteString("Opens the TREE file in an interactive browser, where branches can be expanded and collapsed." + LE)
+	builder.WriteString("Use `-` as TREE to read the tree from stdin." + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Keys:" + LE)
+	builder.WriteString("  j, k, up, down      Move down and up" + LE)
+	builder.WriteString("  l, right            Expand the selected node, or move to its first child" + LE)
+	builder.WriteString("  h, left             Collapse the selected node, or move to its parent" + LE)
+	builder.WriteString("  enter, space        Expand or collapse the selected node" + LE)
+	builder.WriteString("  E, C                Expand or collapse all nodes" + LE)
+	builder.WriteString("  g, G                Move to the first or last node" + LE)
+	builder.WriteString("  ctrl-d, ctrl-u      Move half a screen down or up" + LE)
+	builder.WriteString("  /, n, N             Search, and move to the next or previous match" + LE)
+	builder.WriteString("  y                   Copy the full path of the selected node to the clipboard" + LE)
+	builder.WriteString("  q, ctrl-c           Quit" + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Searches ignore case unless the query contains an uppercase letter. The clipboard is" + LE)
+	builder.WriteString("set with an OSC 52 escape sequence, which most terminals support." + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Options:" + LE)
+	builder.WriteString(flagsHelpText(browseFlags()))
+	return builder
+}
+
+// runBrowse runs the browse command with the given arguments, and returns the exit code.
+//
+// Parameters:
+//
+//	args - The command-line arguments following the `browse` command.
+//
+// Returns:
+//
+//	int - 0 on success, and 2 on errors.
+func runBrowse(args []string) int {
+	opts, err := configuredOptions(args, optionFlags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "treelike: %s\n", err)
+		return 2
+	}
+	positional, err := parseArgs(opts, args, browseFlags())
+	if err == nil && len(positional) != 1 {
+		err = fmt.Errorf("expected a TREE file, got %d arguments", len(positional))
+	}
+	if err != nil {
+		handleArgsError(err, browseHelpText())
+	}
+
+	root, err := readTreeSource(positional[0], opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	transformTree(root, opts)
+
+	if err := browseTree(root, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "treelike: %s\n", err)
+		return 2
+	}
+	return 0
+}
+
+// browseTree runs the browser on the terminal until it is quit. The terminal is switched to raw mode and
+// the alternate screen, and restored when the browser exits, including when the process is sent SIGINT,
+// SIGTERM or SIGHUP.
+//
+// Parameters:
+//
+//	root - The root node of the tree to browse.
+//	opts - A pointer to an Options struct that specifies formatting options.
+//
+// Returns:
+//
+//	error - An error object if the terminal could not be used, otherwise nil.
+func browseTree(root *Node, opts *Options) error {
+	term, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	defer term.close()
+	term.WriteString(ANSI_ALT_SCREEN + ANSI_HIDE_CURSOR)
+	defer term.WriteString(ANSI_SHOW_CURSOR + ANSI_MAIN_SCREEN)
+
+	keys := make(chan []string)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 256)
+		for {
+			n, err := term.Read(buf)
+			if err != nil {
+				return
+			}
+			select {
+			case keys <- decodeKeys(buf[:n]):
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	b := newBrowser(root, opts)
+	for {
+		width, height := term.size()
+		b.resize(width, height)
+		frame := "\x1b[H" + strings.Join(b.lines(), ANSI_CLEAR_LINE+"\r\n") + ANSI_CLEAR_LINE
+		if _, err := term.WriteString(frame + b.takeYank()); err != nil {
+			return err
+		}
+
+		select {
+		case pressed, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, key := range pressed {
+				b.handleKey(key)
+			}
+			if b.quit {
+				_, err := term.WriteString(b.takeYank())
+				return err
+			}
+		case <-term.resized:
+		case sig := <-term.interrupted:
+			return fmt.Errorf("interrupted by %v", sig)
+		}
+	}
+}
+
+// takeYank returns the escape sequence that copies the text yanked since the last call to the clipboard,
+// with OSC 52, or an empty string if nothing was yanked.
+func (b *browser) takeYank() string {
+	if b.yank == "" {
+		return ""
+	}
+	sequence := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(b.yank)) + "\a"
+	b.yank = ""
+	return sequence
+}
+
+// decodeKeys splits the bytes read from a terminal into key names. Printable characters are returned as
+// they are, and control characters and escape sequences are returned as names such as `up`, `enter` or
+// `ctrl-d`. Unknown escape sequences are ignored.
+//
+// Parameters:
+//
+//	input - The bytes read from the terminal.
+//
+// Returns:
+//
+//	[]string - The names of the keys, in order.
+func decodeKeys(input []byte) []string {
+	sequences := map[string]string{
+		"A": "up", "B": "down", "C": "right", "D": "left", "H": "home", "F": "end",
+		"1~": "home", "4~": "end", "7~": "home", "8~": "end", "5~": "pgup", "6~": "pgdown", "3~": "delete",
+	}
+	controls := map[byte]string{
+		'\r': "enter", '\n': "enter", '\t': "tab", 0x7f: "backspace", 0x08: "backspace",
+	}
+
+	var keys []string
+	for len(input) > 0 {
+		c := input[0]
+		switch {
+		case c == 0x1b && len(input) > 2 && (input[1] == '[' || input[1] == 'O'):
+			end := 2
+			for end < len(input) && (input[end] < 0x40 || input[end] > 0x7e) {
+				end++
+			}
+			if end == len(input) {
+				return keys
+			}
+			if name, ok := sequences[string(input[2:end+1])]; ok {
+				keys = append(keys, name)
+			}
+			input = input[end+1:]
+		case c == 0x1b:
+			keys = append(keys, "esc")
+			input = input[1:]
+		case controls[c] != "":
+			keys = append(keys, controls[c])
+			input = input[1:]
+		case c < 0x20:
+			keys = append(keys, "ctrl-"+string(rune('a'+c-1)))
+			input = input[1:]
+		default:
+			r, size := utf8.DecodeRune(input)
+			keys = append(keys, string(r))
+			input = input[size:]
+		}
+	}
+	return keys
+}
+
+// newBrowser creates a browser for a tree, with its top-level nodes expanded.
+//
+// Parameters:
+//
+//	root - The root node of the tree to browse.
+//	opts - A pointer to an Options struct that specifies formatting options.
+//
+// Returns:
+//
+//	*browser - The browser, with a screen of 80 by 24 until it is resized.
+func newBrowser(root *Node, opts *Options) *browser {
+	b := &browser{root: root, opts: opts, order: map[*Node]int{}, expanded: map[*Node]bool{root: true}, width: 80, height: 24}
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		for _, child := range node.children {
+			b.order[child] = len(b.all)
+			b.all = append(b.all, child)
+			walk(child)
+		}
+	}
+	walk(root)
+	for _, child := range root.children {
+		b.expanded[child] = true
+	}
+	b.update(nil)
+	return b
+}
+
+// update rebuilds the displayed rows from the expanded nodes, and selects the given node, or its closest
+// displayed ancestor if it is hidden in a collapsed node.
+//
+// Parameters:
+//
+//	selected - The node to select, or nil to keep the selected row.
+func (b *browser) update(selected *Node) {
+	if selected == nil {
+		selected = b.selected()
+	}
+	b.rows = b.rows[:0]
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		for _, child := range node.children {
+			b.rows = append(b.rows, child)
+			if b.expanded[child] {
+				walk(child)
+			}
+		}
+	}
+	walk(b.root)
+
+	for node := selected; node != nil && node != b.root; node = node.parent {
+		if index := slices.Index(b.rows, node); index >= 0 {
+			b.cursor = index
+			return
+		}
+	}
+	b.cursor = min(b.cursor, max(len(b.rows)-1, 0))
+}
+
+// selected returns the selected node, or nil if the tree is empty.
+func (b *browser) selected() *Node {
+	if b.cursor < len(b.rows) {
+		return b.rows[b.cursor]
+	}
+	return nil
+}
+
+// resize sets the size of the screen, and scrolls to keep the selected row on it.
+//
+// Parameters:
+//
+//	width - The number of columns of the screen.
+//	height - The number of lines of the screen, including the status line.
+func (b *browser) resize(width int, height int) {
+	b.width, b.height = max(width, 1), max(height, 2)
+	b.scroll()
+}
+
+// scroll moves the first row on the screen so the selected row is on it.
+func (b *browser) scroll() {
+	page := b.height - 1
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+page {
+		b.offset = b.cursor - page + 1
+	}
+	b.offset = max(min(b.offset, len(b.rows)-page), 0)
+}
+
+// move moves the selection by the given number of rows, stopping at the first and last ones.
+func (b *browser) move(delta int) {
+	b.cursor = max(min(b.cursor+delta, len(b.rows)-1), 0)
+}
+
+// handleKey updates the browser for a key returned by decodeKeys.
+//
+// Parameters:
+//
+//	key - The name of the key.
+func (b *browser) handleKey(key string) {
+	b.message = ""
+	if b.searching {
+		b.handleSearchKey(key)
+		b.scroll()
+		return
+	}
+
+	node := b.selected()
+	page := b.height - 1
+	switch key {
+	case "q", "ctrl-c":
+		b.quit = true
+	case "j", "down", "ctrl-n":
+		b.move(1)
+	case "k", "up", "ctrl-p":
+		b.move(-1)
+	case "g", "home":
+		b.cursor = 0
+	case "G", "end":
+		b.move(len(b.rows))
+	case "ctrl-d":
+		b.move(page / 2)
+	case "ctrl-u":
+		b.move(-page / 2)
+	case "ctrl-f", "pgdown":
+		b.move(page)
+	case "ctrl-b", "pgup":
+		b.move(-page)
+	case "l", "right":
+		if node != nil && len(node.children) > 0 {
+			if b.expanded[node] {
+				b.move(1)
+			} else {
+				b.expanded[node] = true
+				b.update(node)
+			}
+		}
+	case "h", "left":
+		if node != nil && b.expanded[node] && len(node.children) > 0 {
+			b.expanded[node] = false
+			b.update(node)
+		} else if node != nil && node.parent != b.root {
+			b.update(node.parent)
+		}
+	case "enter", " ", "o":
+		if node != nil && len(node.children) > 0 {
+			b.expanded[node] = !b.expanded[node]
+			b.update(node)
+		}
+	case "E":
+		for _, node := range b.all {
+			b.expanded[node] = true
+		}
+		b.update(node)
+	case "C":
+		b.expanded = map[*Node]bool{b.root: true}
+		b.update(node)
+	case "/":
+		b.searching = true
+		b.query = ""
+		b.searchFrom = node
+		b.searchExpanded = maps.Clone(b.expanded)
+	case "n":
+		b.searchNext(1, 1)
+	case "N":
+		b.searchNext(-1, 1)
+	case "y":
+		if node != nil && node.hidden > 0 {
+			b.message = "nothing copied: elided nodes have no path"
+		} else if node != nil {
+			b.yank = b.path(node)
+			b.message = "copied: " + b.yank
+		}
+	}
+	b.scroll()
+}
+
+// handleSearchKey updates the search query for a key typed while searching, and selects the first match
+// from where the search started.
+//
+// Parameters:
+//
+//	key - The name of the key.
+func (b *browser) handleSearchKey(key string) {
+	switch key {
+	case "enter":
+		b.searching = false
+		if b.query != "" && !b.matches(b.selected()) {
+			b.message = "not found: " + b.query
+		}
+		return
+	case "esc", "ctrl-c":
+		b.searching = false
+		b.expanded = b.searchExpanded
+		b.update(b.searchFrom)
+		return
+	case "backspace":
+		if b.query == "" {
+			b.handleSearchKey("esc")
+			return
+		}
+		_, size := utf8.DecodeLastRuneInString(b.query)
+		b.query = b.query[:len(b.query)-size]
+	default:
+		if utf8.RuneCountInString(key) != 1 {
+			return
+		}
+		b.query += key
+	}
+
+	b.expanded = maps.Clone(b.searchExpanded)
+	b.update(b.searchFrom)
+	if b.query != "" {
+		b.searchNext(1, 0)
+	}
+}
+
+// searchNext selects the next node in tree order, or the previous one, whose name matches the search
+// query, wrapping around at the end of the tree. Collapsed ancestors of the match are expanded.
+//
+// Parameters:
+//
+//	direction - 1 to search forward, or -1 to search backward.
+//	skip - 1 to start after the selected node, or 0 to include it.
+func (b *browser) searchNext(direction int, skip int) {
+	node := b.selected()
+	if b.query == "" || node == nil {
+		return
+	}
+	start := b.order[node]
+	for i := 0; i < len(b.all); i++ {
+		index := ((start+direction*(i+skip))%len(b.all) + len(b.all)) % len(b.all)
+		if match := b.all[index]; b.matches(match) {
+			for ancestor := match.parent; ancestor != nil; ancestor = ancestor.parent {
+				b.expanded[ancestor] = true
+			}
+			b.update(match)
+			return
+		}
+	}
+	b.message = "not found: " + b.query
+}
+
+// matches reports whether the name of a node contains the search query. The query ignores case unless it
+// contains an uppercase letter.
+func (b *browser) matches(node *Node) bool {
+	if node == nil || b.query == "" {
+		return false
+	}
+	if strings.IndexFunc(b.query, unicode.IsUpper) >= 0 {
+		return strings.Contains(node.name, b.query)
+	}
+	return strings.Contains(strings.ToLower(node.name), strings.ToLower(b.query))
+}
+
+// lines returns the lines of the screen: the rows that fit on it, with the selected one highlighted, and
+// a status line with the search query, a message, or the path of the selected node and its position.
+//
+// Returns:
+//
+//	[]string - The lines of the screen, each at most as wide as the screen.
+func (b *browser) lines() []string {
+	page := b.height - 1
+	lines := make([]string, 0, b.height)
+	for i := b.offset; i < b.offset+page; i++ {
+		if i >= len(b.rows) {
+			lines = append(lines, "")
+			continue
+		}
+		node := b.rows[i]
+		line := getTreeLine(node, b.opts)
+		if len(node.children) > 0 && !b.expanded[node] {
+			line += " " + getElisionName(&Node{hidden: countDescendants(node)}, b.opts)
+		}
+		line = truncateLine(line, b.width, b.opts)
+		if i == b.cursor {
+			line = ANSI_REVERSE + line + ANSI_RESET
+		}
+		lines = append(lines, line)
+	}
+
+	status := b.message
+	if b.searching {
+		status = "/" + b.query
+	} else if status == "" && len(b.rows) > 0 {
+		node := b.selected()
+		status = b.path(node) + " (" + strconv.Itoa(b.cursor+1) + "/" + strconv.Itoa(len(b.rows)) + ")"
+	}
+	return append(lines, truncateLine(status, b.width, b.opts))
+}
+
+// path returns the full path of a node, as displayed with the fullPath option. Elision nodes, which stand
+// in for the nodes hidden by the maxDepth option, have no path of their own, so their name is displayed
+// after the path of their parent.
+func (b *browser) path(node *Node) string {
+	if node.hidden > 0 {
+		return getPathPrefix(node.parent) + getElisionName(node, b.opts)
+	}
+	return joinPath(getPathPrefix(node.parent), node.name)
+}
+
+// truncateLine shortens a line to the given number of columns, ending it with an ellipsis if it was cut.
+//
+// Parameters:
+//
+//	line - The line to shorten.
+//	width - The maximum number of columns.
+//	opts - A pointer to an Options struct that specifies the charset.
+//
+// Returns:
+//
+//	string - The line, at most width columns wide.
+func truncateLine(line string, width int, opts *Options) string {
+	if displayWidth(line) <= width {
+		return line
+	}
+	ellipsis := UTF8_ELLIPSIS
+	if opts.charset == "ascii" {
+		ellipsis = ASCII_ELLIPSIS
+	}
+	available := width - displayWidth(ellipsis)
+	used := 0
+	for i, r := range line {
+		if used+runeWidth(r) > available {
+			return line[:i] + ellipsis
+		}
+		used += runeWidth(r)
+	}
+	return line
+}
+
+// displayWidth returns the number of terminal columns a string takes, as measured by runeWidth.
+func displayWidth(str string) int {
+	width := 0
+	for _, r := range str {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth returns the number of terminal columns a character takes: 2 for East Asian wide and fullwidth
+// characters and most emoji, 0 for combining marks and other zero-width characters, and 1 otherwise. The
+// ranges cover the common scripts rather than the whole Unicode width table, so rare characters may still
+// be measured as 1 column.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0x1100 && r <= 0x115f, r >= 0x2e80 && r <= 0x303e, r >= 0x3041 && r <= 0x33ff,
+		r >= 0x3400 && r <= 0x4dbf, r >= 0x4e00 && r <= 0x9fff, r >= 0xa000 && r <= 0xa4cf,
+		r >= 0xac00 && r <= 0xd7a3, r >= 0xf900 && r <= 0xfaff, r >= 0xfe30 && r <= 0xfe4f,
+		r >= 0xff00 && r <= 0xff60, r >= 0xffe0 && r <= 0xffe6, r >= 0x1f300 && r <= 0x1f64f,
+		r >= 0x1f900 && r <= 0x1f9ff, r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// browseKeys creates a browser for an input on a screen of 40 by 6, and presses the given keys.
+func browseKeys(t *testing.T, input string, keys ...string) *browser {
+	opts := DefaultOptions()
+	b := newBrowser(mustParseInput(t, input, opts), opts)
+	b.resize(40, 6)
+	for _, key := range keys {
+		b.handleKey(key)
+	}
+	return b
+}
+
+func TestDecodeKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"jk/é", []string{"j", "k", "/", "é"}},
+		{"\x1b[A\x1b[B\x1bOC\x1b[D", []string{"up", "down", "right", "left"}},
+		{"\x1b[5~\x1b[6~\x1b[H\x1b[4~", []string{"pgup", "pgdown", "home", "end"}},
+		{"\r\x7f\x1b", []string{"enter", "backspace", "esc"}},
+		{"\x03\x04\x15", []string{"ctrl-c", "ctrl-d", "ctrl-u"}},
+		{"\x1b[1;5Aq", []string{"q"}},
+		{"a\x1b[1", []string{"a"}},
+	}
+
+	for _, test := range tests {
+		actual := decodeKeys([]byte(test.input))
+		if !slices.Equal(actual, test.expected) {
+			t.Errorf("decodeKeys(%q)\nactual = %q\nwant   = %q", test.input, actual, test.expected)
+		}
+	}
+}
+
+func TestBrowserNavigation(t *testing.T) {
+	input := "a\n  b\n    c\n  d\ne\n  f\n"
+	tests := []struct {
+		keys     []string
+		expected []string
+	}{
+		{[]string{}, []string{"a", "b", "d", "e", "f"}},
+		{[]string{"j", "l"}, []string{"a", "b", "c", "d", "e", "f"}},
+		{[]string{"j", "l", "l", "h", "h"}, []string{"a", "b", "d", "e", "f"}},
+		{[]string{"h"}, []string{"a", "e", "f"}},
+		{[]string{"enter", "enter"}, []string{"a", "b", "d", "e", "f"}},
+		{[]string{"C"}, []string{"a", "e"}},
+		{[]string{"E"}, []string{"a", "b", "c", "d", "e", "f"}},
+	}
+
+	for _, test := range tests {
+		b := browseKeys(t, input, test.keys...)
+		var actual []string
+		for _, node := range b.rows {
+			actual = append(actual, node.name)
+		}
+		if !slices.Equal(actual, test.expected) {
+			t.Errorf("browser rows after %v\nactual = %q\nwant   = %q", test.keys, actual, test.expected)
+		}
+	}
+}
+
+func TestBrowserSelection(t *testing.T) {
+	input := "a\n  b\n    c\n  d\ne\n  f\n"
+	tests := []struct {
+		keys     []string
+		expected string
+	}{
+		{[]string{}, "a"},
+		{[]string{"j", "j"}, "d"},
+		{[]string{"k", "k"}, "a"},
+		{[]string{"G"}, "f"},
+		{[]string{"G", "g"}, "a"},
+		{[]string{"j", "l", "l"}, "c"},
+		{[]string{"j", "l", "l", "h", "h"}, "b"},
+		{[]string{"G", "h"}, "e"},
+		{[]string{"G", "C"}, "e"},
+		{[]string{"ctrl-d"}, "d"},
+		{[]string{"/", "c"}, "c"},
+		{[]string{"/", "c", "backspace", "backspace"}, "a"},
+		{[]string{"j", "/", "F", "esc"}, "b"},
+		{[]string{"/", "f", "enter"}, "f"},
+		{[]string{"/", "d", "enter", "n"}, "d"},
+		{[]string{"/", "up", "enter"}, "a"},
+		{[]string{"/", "B", "enter"}, "a"},
+		{[]string{"/", "e", "enter", "N"}, "e"},
+	}
+
+	for _, test := range tests {
+		b := browseKeys(t, input, test.keys...)
+		if actual := b.selected().name; actual != test.expected {
+			t.Errorf("browser selection after %v\nactual = %q\nwant   = %q", test.keys, actual, test.expected)
+		}
+	}
+}
+
+func TestBrowserSearchReveal(t *testing.T) {
+	b := browseKeys(t, "a\n  b\n    c\n", "C", "/", "c")
+	if actual := b.lines()[5]; actual != "/c" {
+		t.Errorf("browser status\nactual = %q\nwant   = %q", actual, "/c")
+	}
+	b.handleKey("esc")
+	if actual := len(b.rows); actual != 1 {
+		t.Errorf("browser rows after cancelling the search = %d, want 1", actual)
+	}
+}
+
+func TestBrowserLines(t *testing.T) {
+	b := browseKeys(t, "a\n  b\n    c\n  d\n    e\n    f\n  g\nh\n", "j", "j")
+	expected := []string{
+		"├── a",
+		"│   ├── b " + UTF8_ELLIPSIS + " (1 more)",
+		ANSI_REVERSE + "│   ├── d " + UTF8_ELLIPSIS + " (2 more)" + ANSI_RESET,
+		"│   └── g",
+		"└── h",
+		"./a/d (3/5)",
+	}
+	if actual := b.lines(); !slices.Equal(actual, expected) {
+		t.Errorf("browser lines\nactual = %q\nwant   = %q", actual, expected)
+	}
+
+	b.handleKey("G")
+	b.handleKey("y")
+	if b.yank != "./h" {
+		t.Errorf("browser yank\nactual = %q\nwant   = %q", b.yank, "./h")
+	}
+	if actual := b.lines()[5]; actual != "copied: ./h" {
+		t.Errorf("browser status\nactual = %q\nwant   = %q", actual, "copied: ./h")
+	}
+	if actual := b.takeYank(); actual != "\x1b]52;c;Li9o\a" {
+		t.Errorf("browser yank sequence\nactual = %q", actual)
+	}
+	if actual := b.takeYank(); actual != "" {
+		t.Errorf("browser yank sequence after it was taken\nactual = %q", actual)
+	}
+}
+
+func TestBrowserScroll(t *testing.T) {
+	b := browseKeys(t, generateTree(50, 0), "G")
+	lines := b.lines()
+	if actual := lines[4]; actual != ANSI_REVERSE+"└── node49"+ANSI_RESET {
+		t.Errorf("browser last row\nactual = %q", actual)
+	}
+	if actual := lines[0]; actual != "├── node45" {
+		t.Errorf("browser first row\nactual = %q", actual)
+	}
+
+	b.resize(12, 6)
+	b.handleKey("k")
+	lines = b.lines()
+	if actual := lines[3]; actual != ANSI_REVERSE+"├── node48"+ANSI_RESET {
+		t.Errorf("browser selected row\nactual = %q", actual)
+	}
+	if actual := lines[5]; actual != "./node48 (4"+UTF8_ELLIPSIS {
+		t.Errorf("browser truncated status\nactual = %q", actual)
+	}
+	if b.offset != 45 {
+		t.Errorf("browser scrolled to %d, want 45", b.offset)
+	}
+}
+
+func TestBrowserEmpty(t *testing.T) {
+	b := browseKeys(t, "", "j", "l", "/", "a", "enter", "y", "E")
+	if b.selected() != nil || b.yank != "" || len(b.lines()) != 6 {
+		t.Errorf("browser on an empty tree = %q", b.lines())
+	}
+	b.handleKey("q")
+	if !b.quit {
+		t.Errorf("browser did not quit")
+	}
+}
+
+func TestBrowserElision(t *testing.T) {
+	opts := DefaultOptions()
+	opts.maxDepth = 1
+	root := mustParseInput(t, "a\n  b\n    c\n", opts)
+	transformTree(root, opts)
+	b := newBrowser(root, opts)
+	b.resize(40, 6)
+	b.handleKey("E")
+	b.handleKey("G")
+	expected := "./a/" + UTF8_ELLIPSIS + " (2 more) (2/2)"
+	if actual := b.lines()[5]; actual != expected {
+		t.Errorf("browser status on an elision\nactual = %q\nwant   = %q", actual, expected)
+	}
+	b.handleKey("y")
+	if b.yank != "" || b.message != "nothing copied: elided nodes have no path" {
+		t.Errorf("browser yanked an elision: %q, %q", b.yank, b.message)
+	}
+}
+
+func TestTruncateLine(t *testing.T) {
+	ascii := DefaultOptions()
+	ascii.charset = "ascii"
+	tests := []struct {
+		line     string
+		width    int
+		opts     *Options
+		expected string
+	}{
+		{"├── main.go", 11, DefaultOptions(), "├── main.go"},
+		{"├── main.go", 10, DefaultOptions(), "├── main." + UTF8_ELLIPSIS},
+		{"|-- main.go", 10, ascii, "|-- mai..."},
+		{"└── 文档目录", 12, DefaultOptions(), "└── 文档目录"},
+		{"└── 文档目录", 11, DefaultOptions(), "└── 文档目" + UTF8_ELLIPSIS},
+		{"└── 文档目录", 10, DefaultOptions(), "└── 文档" + UTF8_ELLIPSIS},
+		{"└── été", 7, DefaultOptions(), "└── été"},
+	}
+
+	for _, test := range tests {
+		actual := truncateLine(test.line, test.width, test.opts)
+		if actual != test.expected {
+			t.Errorf("truncateLine(%q, %d)\nactual = %q\nwant   = %q", test.line, test.width, actual, test.expected)
+		}
+		if width := displayWidth(actual); width > test.width {
+			t.Errorf("truncateLine(%q, %d) is %d columns wide", test.line, test.width, width)
+		}
+	}
+}
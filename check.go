@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// checkHelpText generates and returns a strings.Builder containing the help text for the check command.
+//
+// Returns:
+//
+//	strings.Builder - A builder containing the formatted help text.
+func checkHelpText() strings.Builder {
+	LE := getLE()
+	var builder strings.Builder
+	builder.WriteString("Usage: treelike check [OPTIONS] TREE DIR" + LE)
+	builder.WriteString("Compares the TREE file with the contents of DIR and reports:" + LE)
+	builder.WriteString("  missing: entries in the tree that do not exist in DIR" + LE)
+	builder.WriteString("  extra: entries in DIR that are not in the tree" + LE)
+	builder.WriteString("  mismatch: entries that are a file in one and a directory in the other" + LE)
+	builder.WriteString("Use `-` as TREE to read the tree from stdin." + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Directories without children in the tree are only checked for extra entries if they" + LE)
+	builder.WriteString("are explicitly marked as directories, with a trailing `/` or a `type=dir` attribute." + LE)
+	builder.WriteString("Exits with 0 if they match, 1 if they differ, and 2 on errors." + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Include and exclude patterns are applied to both the tree and the entries of DIR." + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Options:" + LE)
+	builder.WriteString(flagsHelpText(checkFlags()))
+	return builder
+}
+
+// checkFlags returns the flags of the check command.
+func checkFlags() []flagDef {
+	return slices.Concat(commonFlags, optionFlags)
+}
+
+// runCheck runs the check command with the given arguments, and returns the exit code.
+//
+// Parameters:
+//
+//	args - The command-line arguments following the `check` command.
+//
+// Returns:
+//
+//	int - 0 if the tree matches the directory, 1 if they differ, and 2 on errors.
+func runCheck(args []string) int {
+	opts, err := configuredOptions(args, optionFlags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "treelike: %s\n", err)
+		return 2
+	}
+	positional, err := parseArgs(opts, args, checkFlags())
+	if err == nil && len(positional) != 2 {
+		err = fmt.Errorf("expected TREE and DIR, got %d arguments", len(positional))
+	}
+	if err != nil {
+		handleArgsError(err, checkHelpText())
+	}
+
+	treeFile, dir := positional[0], positional[1]
+	root, err := readTreeSource(treeFile, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if opts.mergeDupes {
+		mergeDuplicates(root)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening directory %s: %v\n", dir, err)
+		return 2
+	}
+	if !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "error opening directory %s: not a directory\n", dir)
+		return 2
+	}
+
+	issues, err := checkTree(root, dir, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	if len(issues) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// checkTree compares a tree with the contents of a directory, and returns a description of each difference.
+// The include and exclude patterns are applied to both the tree and the directory, the same way filterTree
+// applies them, so only the entries that would be displayed are compared.
+//
+// Parameters:
+//
+//	root - The root node of the tree, which stands for the directory itself.
+//	dir - The path of the directory to compare with.
+//	opts - A pointer to an Options struct that specifies the include and exclude patterns.
+//
+// Returns:
+//
+//	[]string - The differences, such as `missing: usr/bin/zsh`, in tree order.
+//	error - An error object if a directory could not be read, otherwise nil.
+func checkTree(root *Node, dir string, opts *Options) ([]string, error) {
+	filterTree(root, opts)
+	var issues []string
+	err := checkChildren(root, dir, len(opts.includes) == 0, opts, &issues)
+	return issues, err
+}
+
+// checkChildren recursively compares the children of a node with the entries of the matching directory.
+//
+// Parameters:
+//
+//	node - The node whose children to compare.
+//	dir - The path of the directory matching the node.
+//	included - Whether the node, or one of its ancestors, matched an include pattern.
+//	opts - A pointer to an Options struct that specifies the include and exclude patterns for directory entries.
+//	issues - The list of differences to add to.
+//
+// Returns:
+//
+//	error - An error object if a directory could not be read, otherwise nil.
+func checkChildren(node *Node, dir string, included bool, opts *Options, issues *[]string) error {
+	described := map[string]bool{}
+
+	for _, child := range node.children {
+		name := nodeKey(child)
+		described[strings.SplitN(name, "/", 2)[0]] = true
+		path := nodePath(child)
+
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+		if os.IsNotExist(err) {
+			*issues = append(*issues, "missing: "+path)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", path, err)
+		}
+
+		if child.isDir() && !info.IsDir() {
+			*issues = append(*issues, "mismatch: "+path+" (expected directory, found file)")
+			continue
+		}
+		if child.kind == KIND_FILE && info.IsDir() {
+			*issues = append(*issues, "mismatch: "+path+" (expected file, found directory)")
+			continue
+		}
+		if child.isDir() {
+			childIncluded := included || matchesAny(opts.includes, child)
+			if err := checkChildren(child, filepath.Join(dir, filepath.FromSlash(name)), childIncluded, opts, issues); err != nil {
+				return err
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading directory %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		if described[entry.Name()] {
+			continue
+		}
+		entryNode := entryToNode(entry, node)
+		if keepEntry(entryNode, filepath.Join(dir, entry.Name()), included, opts) {
+			*issues = append(*issues, "extra: "+nodePath(entryNode))
+		}
+	}
+	return nil
+}
+
+// entryToNode creates a node for a directory entry, to match it against the include and exclude patterns.
+func entryToNode(entry os.DirEntry, parent *Node) *Node {
+	node := &Node{name: entry.Name(), depth: parent.depth + 1, children: []*Node{}, parent: parent, kind: KIND_FILE}
+	if entry.IsDir() {
+		node.kind = KIND_DIR
+	}
+	return node
+}
+
+// keepEntry reports whether filterTree would keep a directory entry if it were in the tree: it is not
+// excluded, and it or one of its ancestors matches an include pattern, or it is a directory with an entry
+// below it that is kept. Directories are only read when no include pattern matched above them.
+//
+// Parameters:
+//
+//	entry - The node created for the entry, with its parent set.
+//	path - The path of the entry.
+//	included - Whether one of the entry's ancestors matched an include pattern.
+//	opts - A pointer to an Options struct that specifies the include and exclude patterns.
+//
+// Returns:
+//
+//	bool - True if the entry is kept, false otherwise.
+func keepEntry(entry *Node, path string, included bool, opts *Options) bool {
+	if matchesAny(opts.excludes, entry) {
+		return false
+	}
+	if included || matchesAny(opts.includes, entry) {
+		return true
+	}
+	if entry.kind != KIND_DIR {
+		return false
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false
+	}
+	for _, child := range entries {
+		if keepEntry(entryToNode(child, entry), filepath.Join(path, child.Name()), false, opts) {
+			return true
+		}
+	}
+	return false
+}
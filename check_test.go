@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckTree(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"usr/bin/sh", "usr/bin/bash", "usr/sbin", "usr/local/lib/x", "etc/hosts", ".git/HEAD"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), []byte{}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	input := "usr\n  local\n  bin\n    sh\n    zsh\n  sbin/\n  lib/\netc {type=file}\n"
+	opts := DefaultOptions()
+	pattern, _ := parsePattern(".git")
+	opts.excludes = append(opts.excludes, pattern)
+
+	issues, err := checkTree(mustParseInput(t, input, opts), dir, opts)
+	if err != nil {
+		t.Fatalf("checkTree() failed: %v", err)
+	}
+
+	expected := []string{
+		"missing: usr/bin/zsh",
+		"extra: usr/bin/bash",
+		"mismatch: usr/sbin (expected directory, found file)",
+		"missing: usr/lib",
+		"mismatch: etc (expected file, found directory)",
+	}
+	if strings.Join(issues, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("checkTree()\n actual = %q\nwant   = %q", issues, expected)
+	}
+}
+
+func TestCheckTreeIncludes(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"src/main.go", "src/util.go", "src/notes.txt", "docs/guide.md", "build/out/app", "lib/vendor/x.go", "lib/vendor/x.txt"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), []byte{}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		args     []string
+		expected []string
+	}{
+		{"src\n  main.go\n", []string{"-P", "*.go"}, []string{"extra: src/util.go", "extra: lib"}},
+		{"src\n  main.go\n  util.go\nlib\n  vendor\n    x.go\n", []string{"-P", "*.go"}, nil},
+		{"src\n  main.go\n  util.go\n  cli.go\nREADME.md\n", []string{"-P", "*.go", "-X", "lib"}, []string{"missing: src/cli.go"}},
+		{"src\n  main.go\n", []string{"-P", "src"}, []string{"extra: src/notes.txt", "extra: src/util.go"}},
+		{"docs\n  guide.md\n", []string{"-P", "re:^docs"}, nil},
+	}
+
+	for _, test := range tests {
+		opts := DefaultOptions()
+		if _, err := parseArgs(opts, test.args, optionFlags); err != nil {
+			t.Fatal(err)
+		}
+		issues, err := checkTree(mustParseInput(t, test.input, opts), dir, opts)
+		if err != nil {
+			t.Fatalf("checkTree() failed: %v", err)
+		}
+		if strings.Join(issues, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("checkTree(%v)\nactual = %q\nwant   = %q", test.args, issues, test.expected)
+		}
+	}
+}
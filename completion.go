@@ -0,0 +1,314 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// completionShells lists the shells that completion scripts can be generated for.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completionFlags returns the flags of the completion command.
+func completionFlags() []flagDef {
+	return commonFlags
+}
+
+// completionHelpText generates and returns a strings.Builder containing the help text for the completion command.
+//
+// Returns:
+//
+//	strings.Builder - A builder containing the formatted help text.
+func completionHelpText() strings.Builder {
+	LE := getLE()
+	var builder strings.Builder
+	builder.WriteString("Usage: treelike completion SHELL" + LE)
+	builder.WriteString("Prints a completion script for SHELL (" + strings.Join(completionShells, ", ") + ")." + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Examples:" + LE)
+	builder.WriteString("  treelike completion bash > /etc/bash_completion.d/treelike" + LE)
+	builder.WriteString("  treelike completion zsh > \"${fpath[1]}/_treelike\"" + LE)
+	builder.WriteString("  treelike completion fish > ~/.config/fish/completions/treelike.fish" + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Options:" + LE)
+	builder.WriteString(flagsHelpText(completionFlags()))
+	return builder
+}
+
+// runCompletion runs the completion command with the given arguments, and returns the exit code.
+//
+// Parameters:
+//
+//	args - The command-line arguments following the `completion` command.
+//
+// Returns:
+//
+//	int - 0 on success, and 2 on errors.
+func runCompletion(args []string) int {
+	positional, err := parseArgs(DefaultOptions(), args, completionFlags())
+	if err == nil && len(positional) != 1 {
+		err = fmt.Errorf("expected SHELL, got %d arguments", len(positional))
+	}
+	if err == nil && !slices.Contains(completionShells, positional[0]) {
+		err = fmt.Errorf("unsupported shell: %s (valid values: %s)", positional[0], strings.Join(completionShells, ", "))
+	}
+	if err != nil {
+		handleArgsError(err, completionHelpText())
+	}
+
+	switch positional[0] {
+	case "bash":
+		fmt.Print(bashCompletion())
+	case "zsh":
+		fmt.Print(zshCompletion())
+	case "fish":
+		fmt.Print(fishCompletion())
+	}
+	return 0
+}
+
+// flagNames returns the names of a flag with their dashes, e.g. `-f` and `--file`. The bare `-` stdin
+// flag is left out, as it can not be completed.
+func flagNames(flag flagDef) []string {
+	names := []string{"--" + flag.long}
+	if flag.short != "" && flag.short != "-" {
+		names = append([]string{"-" + flag.short}, names...)
+	}
+	return names
+}
+
+// firstLine returns the first line of a flag's usage, for shells that only show one line.
+func firstLine(usage string) string {
+	line, _, _ := strings.Cut(usage, "\n")
+	return line
+}
+
+// bashCompletion generates the bash completion script.
+//
+// Returns:
+//
+//	string - The bash completion script.
+func bashCompletion() string {
+	var builder strings.Builder
+	builder.WriteString("# bash completion for treelike, generated by `treelike completion bash`\n")
+	builder.WriteString("_treelike() {\n")
+	builder.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\" cmd=\"\" opts=\"\"\n")
+	builder.WriteString("    if [[ ${COMP_CWORD} -gt 1 ]]; then\n")
+	builder.WriteString("        cmd=\"${COMP_WORDS[1]}\"\n")
+	builder.WriteString("    fi\n\n")
+
+	var allFlags []flagDef
+	builder.WriteString("    case \"$cmd\" in\n")
+	for _, command := range commands {
+		flags := command.flags()
+		allFlags = append(allFlags, flags...)
+		fmt.Fprintf(&builder, "        %s) opts=%q ;;\n", command.name, bashFlagWords(flags))
+	}
+	mainFlagList := mainFlags()
+	allFlags = append(allFlags, mainFlagList...)
+	fmt.Fprintf(&builder, "        *) opts=%q ;;\n", bashFlagWords(mainFlagList))
+	builder.WriteString("    esac\n\n")
+
+	builder.WriteString("    case \"$prev\" in\n")
+	seen := map[string]bool{}
+	for _, flag := range allFlags {
+		if flag.value == "" || seen[flag.long] {
+			continue
+		}
+		seen[flag.long] = true
+		pattern := strings.Join(flagNames(flag), "|")
+		switch {
+		case len(flag.choices) > 0:
+			fmt.Fprintf(&builder, "        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", pattern, strings.Join(flag.choices, " "))
+		case flag.value == "FILE":
+			fmt.Fprintf(&builder, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", pattern)
+		default:
+			fmt.Fprintf(&builder, "        %s) return ;;\n", pattern)
+		}
+	}
+	builder.WriteString("    esac\n\n")
+
+	builder.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+	builder.WriteString("        COMPREPLY=($(compgen -W \"$opts\" -- \"$cur\"))\n")
+	builder.WriteString("        return\n")
+	builder.WriteString("    fi\n\n")
+
+	var names []string
+	for _, command := range commands {
+		names = append(names, command.name)
+	}
+	builder.WriteString("    case \"$cmd\" in\n")
+	fmt.Fprintf(&builder, "        \"\") COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", strings.Join(names, " "))
+	for _, command := range commands {
+		if command.choices != nil {
+			fmt.Fprintf(&builder, "        %s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", command.name, strings.Join(command.choices, " "))
+		} else {
+			fmt.Fprintf(&builder, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")) ;;\n", command.name)
+		}
+	}
+	builder.WriteString("    esac\n")
+	builder.WriteString("}\n\n")
+	builder.WriteString("complete -F _treelike treelike\n")
+	return builder.String()
+}
+
+// bashFlagWords returns the names of the given flags as a space-separated word list.
+func bashFlagWords(flags []flagDef) string {
+	var words []string
+	for _, flag := range flags {
+		words = append(words, flagNames(flag)...)
+	}
+	return strings.Join(words, " ")
+}
+
+// zshCompletion generates the zsh completion script.
+//
+// Returns:
+//
+//	string - The zsh completion script.
+func zshCompletion() string {
+	var builder strings.Builder
+	builder.WriteString("#compdef treelike\n")
+	builder.WriteString("# zsh completion for treelike, generated by `treelike completion zsh`\n\n")
+	builder.WriteString("_treelike() {\n")
+	builder.WriteString("  local -a commands\n")
+	builder.WriteString("  commands=(\n")
+	for _, command := range commands {
+		fmt.Fprintf(&builder, "    %s\n", zshQuote(command.name+":"+command.usage))
+	}
+	builder.WriteString("  )\n\n")
+
+	builder.WriteString("  case $words[2] in\n")
+	for _, command := range commands {
+		fmt.Fprintf(&builder, "    %s)\n", command.name)
+		builder.WriteString("      words=(\"${(@)words[2,-1]}\")\n")
+		builder.WriteString("      (( CURRENT-- ))\n")
+		builder.WriteString("      _arguments -s \\\n")
+		for _, spec := range zshFlagSpecs(command.flags()) {
+			fmt.Fprintf(&builder, "        %s \\\n", spec)
+		}
+		if command.choices != nil {
+			fmt.Fprintf(&builder, "        %s\n", zshQuote("*:"+strings.ToLower(command.args)+":("+strings.Join(command.choices, " ")+")"))
+		} else {
+			fmt.Fprintf(&builder, "        %s\n", zshQuote("*:file:_files"))
+		}
+		builder.WriteString("      ;;\n")
+	}
+	builder.WriteString("    *)\n")
+	builder.WriteString("      if (( CURRENT == 2 )) && [[ $PREFIX != -* ]]; then\n")
+	builder.WriteString("        _describe -t commands 'treelike command' commands\n")
+	builder.WriteString("        return\n")
+	builder.WriteString("      fi\n")
+	builder.WriteString("      _arguments -s \\\n")
+	specs := zshFlagSpecs(mainFlags())
+	for i, spec := range specs {
+		if i < len(specs)-1 {
+			fmt.Fprintf(&builder, "        %s \\\n", spec)
+		} else {
+			fmt.Fprintf(&builder, "        %s\n", spec)
+		}
+	}
+	builder.WriteString("      ;;\n")
+	builder.WriteString("  esac\n")
+	builder.WriteString("}\n\n")
+	builder.WriteString("_treelike \"$@\"\n")
+	return builder.String()
+}
+
+// zshFlagSpecs returns the `_arguments` specs for the given flags.
+func zshFlagSpecs(flags []flagDef) []string {
+	var specs []string
+	for _, flag := range flags {
+		description := strings.NewReplacer("[", "\\[", "]", "\\]").Replace(firstLine(flag.usage))
+		action := ""
+		switch {
+		case len(flag.choices) > 0:
+			action = ":" + strings.ToLower(flag.value) + ":(" + strings.Join(flag.choices, " ") + ")"
+		case flag.value == "FILE":
+			action = ":file:_files"
+		case flag.value != "":
+			action = ":" + strings.ToLower(flag.value) + ":"
+		}
+
+		names := flagNames(flag)
+		if len(names) == 1 {
+			prefix := ""
+			if flag.repeat {
+				prefix = "*"
+			}
+			specs = append(specs, zshQuote(prefix+names[0]+"["+description+"]"+action))
+			continue
+		}
+		exclusion := "(" + strings.Join(names, " ") + ")"
+		if flag.repeat {
+			exclusion = "*"
+		}
+		specs = append(specs, zshQuote(exclusion)+"{"+strings.Join(names, ",")+"}"+zshQuote("["+description+"]"+action))
+	}
+	return specs
+}
+
+// zshQuote quotes a string for zsh with single quotes.
+func zshQuote(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", "'\\''") + "'"
+}
+
+// fishCompletion generates the fish completion script.
+//
+// Returns:
+//
+//	string - The fish completion script.
+func fishCompletion() string {
+	var builder strings.Builder
+	var names []string
+	for _, command := range commands {
+		names = append(names, command.name)
+	}
+	noCommand := "not __fish_seen_subcommand_from " + strings.Join(names, " ")
+
+	builder.WriteString("# fish completion for treelike, generated by `treelike completion fish`\n")
+	builder.WriteString("complete -c treelike -f\n\n")
+	for _, command := range commands {
+		fmt.Fprintf(&builder, "complete -c treelike -n %s -a %s -d %s\n", fishQuote(noCommand), command.name, fishQuote(command.usage))
+	}
+	builder.WriteString("\n")
+	for _, flag := range mainFlags() {
+		builder.WriteString(fishFlagLine(flag, noCommand))
+	}
+	for _, command := range commands {
+		condition := "__fish_seen_subcommand_from " + command.name
+		builder.WriteString("\n")
+		if command.choices != nil {
+			fmt.Fprintf(&builder, "complete -c treelike -n %s -x -a %s\n", fishQuote(condition), fishQuote(strings.Join(command.choices, " ")))
+		} else {
+			fmt.Fprintf(&builder, "complete -c treelike -n %s -F\n", fishQuote(condition))
+		}
+		for _, flag := range command.flags() {
+			builder.WriteString(fishFlagLine(flag, condition))
+		}
+	}
+	return builder.String()
+}
+
+// fishFlagLine returns the `complete` command for a flag, enabled when the condition is true.
+func fishFlagLine(flag flagDef, condition string) string {
+	line := "complete -c treelike -n " + fishQuote(condition)
+	if flag.short != "" && flag.short != "-" {
+		line += " -s " + flag.short
+	}
+	line += " -l " + flag.long
+	switch {
+	case len(flag.choices) > 0:
+		line += " -x -a " + fishQuote(strings.Join(flag.choices, " "))
+	case flag.value == "FILE":
+		line += " -r -F"
+	case flag.value != "":
+		line += " -x"
+	}
+	return line + " -d " + fishQuote(firstLine(flag.usage)) + "\n"
+}
+
+// fishQuote quotes a string for fish with single quotes.
+func fishQuote(str string) string {
+	return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(str) + "'"
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompletionScripts(t *testing.T) {
+	scripts := map[string]string{
+		"bash": bashCompletion(),
+		"zsh":  zshCompletion(),
+		"fish": fishCompletion(),
+	}
+
+	for shell, script := range scripts {
+		for _, command := range commands {
+			if !strings.Contains(script, command.name) {
+				t.Errorf("%s completion is missing the %s command", shell, command.name)
+			}
+			for _, flag := range command.flags() {
+				if !strings.Contains(script, flag.long) {
+					t.Errorf("%s completion is missing --%s of the %s command", shell, flag.long, command.name)
+				}
+			}
+		}
+		for _, flag := range mainFlags() {
+			if !strings.Contains(script, flag.long) {
+				t.Errorf("%s completion is missing --%s", shell, flag.long)
+			}
+		}
+		if !strings.Contains(script, "utf-8 ascii") {
+			t.Errorf("%s completion is missing the --charset values", shell)
+		}
+	}
+
+	if !strings.Contains(scripts["bash"], "-f|--file) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;") {
+		t.Errorf("bash completion is missing file completion for --file")
+	}
+	if !strings.Contains(scripts["zsh"], "'*'{-f,--file}'[Read from FILE]:file:_files'") {
+		t.Errorf("zsh completion is missing file completion for --file")
+	}
+	if !strings.Contains(scripts["fish"], "-s f -l file -r -F -d 'Read from FILE'") {
+		t.Errorf("fish completion is missing file completion for --file")
+	}
+}
+
+func TestCompletionScriptsSyntax(t *testing.T) {
+	tests := []struct {
+		shell  string
+		script string
+		args   []string
+	}{
+		{"bash", bashCompletion(), []string{"-n"}},
+		{"zsh", zshCompletion(), []string{"-n"}},
+		{"fish", fishCompletion(), []string{"--no-execute"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.shell, func(t *testing.T) {
+			path, err := exec.LookPath(test.shell)
+			if err != nil {
+				t.Skipf("%s is not installed", test.shell)
+			}
+			file := filepath.Join(t.TempDir(), "treelike."+test.shell)
+			if err := os.WriteFile(file, []byte(test.script), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			output, err := exec.Command(path, append(test.args, file)...).CombinedOutput()
+			if err != nil {
+				t.Errorf("%s completion has invalid syntax: %v\n%s", test.shell, err, output)
+			}
+		})
+	}
+}
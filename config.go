@@ -0,0 +1,351 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	CONFIG_FILE string = ".treelikerc"
+	ENV_PREFIX  string = "TREELIKE_"
+)
+
+// configValue is a single option read from a configuration file.
+type configValue struct {
+	// name of the option, the same as its long flag name
+	key string
+	// values of the option; options that may be repeated can have several
+	values []string
+	// line of the option in the configuration file, or 0 if it is unknown
+	line int
+}
+
+// configuredOptions returns the default options, overridden by the configuration file and then by the
+// TREELIKE_* environment variables. If the arguments contain `--no-config`, the default options are
+// returned as-is.
+//
+// Parameters:
+//
+//	args - The command-line arguments, checked for `--no-config`.
+//	flags - The flags that can be set by the configuration.
+//
+// Returns:
+//
+//	*Options - The configured options.
+//	error - An error object if the configuration is invalid, otherwise nil.
+func configuredOptions(args []string, flags []flagDef) (*Options, error) {
+	opts := DefaultOptions()
+	end := slices.Index(args, "--")
+	if end < 0 {
+		end = len(args)
+	}
+	if slices.Contains(args[:end], "--no-config") {
+		return opts, nil
+	}
+
+	if path := findConfigFile(); path != "" {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening config file %s: %w", path, err)
+		}
+		values, err := parseConfig(string(contents))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, value := range values {
+			if err := applyConfigValue(opts, flags, value); err != nil {
+				if value.line > 0 {
+					return nil, fmt.Errorf("%s: line %d: %w", path, value.line, err)
+				}
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+
+	for _, value := range envConfig(flags) {
+		if err := applyConfigValue(opts, flags, value); err != nil {
+			return nil, fmt.Errorf("environment: %w", err)
+		}
+	}
+
+	return opts, nil
+}
+
+// findConfigFile returns the path of the configuration file to use, or an empty string if there is none.
+// The working directory is searched first, then `$XDG_CONFIG_HOME/treelike/` (by default
+// `~/.config/treelike/`).
+//
+// Returns:
+//
+//	string - The path of the configuration file, or an empty string.
+func findConfigFile() string {
+	candidates := []string{CONFIG_FILE}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		candidates = append(candidates, filepath.Join(configHome, "treelike", CONFIG_FILE))
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// envConfig reads the options set by TREELIKE_* environment variables, such as TREELIKE_CHARSET=ascii or
+// TREELIKE_TRAILING_SLASH=true. The values of options that may be repeated are separated by commas, as
+// split by splitConfigList.
+//
+// Parameters:
+//
+//	flags - The flags that can be set by the environment.
+//
+// Returns:
+//
+//	[]configValue - The options set by the environment.
+func envConfig(flags []flagDef) []configValue {
+	var values []configValue
+	for _, flag := range flags {
+		name := ENV_PREFIX + strings.ToUpper(strings.ReplaceAll(flag.long, "-", "_"))
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if flag.repeat {
+			values = append(values, configValue{key: flag.long, values: splitConfigList(value)})
+		} else {
+			values = append(values, configValue{key: flag.long, values: []string{value}})
+		}
+	}
+	return values
+}
+
+// parseConfig parses the contents of a configuration file. The format is detected from the contents:
+// a JSON object, or TOML or YAML style `key = value` or `key: value` lines. Keys are the long flag names,
+// with either dashes or underscores. Lists can be written as `[a, b]`, or as YAML `- item` lines after
+// a `key:` line without a value. The options may follow a TOML `[treelike]` table header, which is
+// ignored; other tables are not supported.
+//
+// Parameters:
+//
+//	contents - The contents of the configuration file.
+//
+// Returns:
+//
+//	[]configValue - The options set by the file.
+//	error - An error object if the file is malformed, otherwise nil.
+func parseConfig(contents string) ([]configValue, error) {
+	if strings.HasPrefix(strings.TrimSpace(contents), "{") {
+		return parseJSONConfig(contents)
+	}
+
+	var values []configValue
+	listOpen := false
+	for i, line := range strings.Split(strings.ReplaceAll(contents, "\r", ""), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			if table := strings.TrimSpace(trimmed[1 : len(trimmed)-1]); table != "treelike" {
+				return nil, fmt.Errorf("line %d: unsupported table %s, expected [treelike] or no table", i+1, trimmed)
+			}
+			listOpen = false
+			continue
+		}
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok {
+			if !listOpen {
+				return nil, fmt.Errorf("line %d: list item without a `key:` line before it", i+1)
+			}
+			last := &values[len(values)-1]
+			last.values = append(last.values, parseConfigScalar(item))
+			continue
+		}
+
+		sep := strings.IndexAny(trimmed, "=:")
+		if sep <= 0 {
+			return nil, fmt.Errorf("line %d: expected `key = value` or `key: value`", i+1)
+		}
+		key := strings.TrimSpace(trimmed[:sep])
+		value := strings.TrimSpace(trimmed[sep+1:])
+
+		var list []string
+		listOpen = value == ""
+		switch {
+		case value == "":
+			// the values follow as YAML list items
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			list = splitConfigList(value[1 : len(value)-1])
+		default:
+			list = []string{parseConfigScalar(value)}
+		}
+		values = append(values, configValue{key: key, values: list, line: i + 1})
+	}
+	return values, nil
+}
+
+// splitConfigList splits a list of values separated by commas, such as `a, "b,c", re:d{1,3}`. Commas inside
+// quotes or brackets are part of a value, so regular expressions and quoted values can contain them. Each
+// value is parsed with parseConfigScalar, and empty values are left out.
+//
+// Parameters:
+//
+//	list - The values, separated by commas.
+//
+// Returns:
+//
+//	[]string - The values.
+func splitConfigList(list string) []string {
+	var items []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i <= len(list); i++ {
+		if i == len(list) || (list[i] == ',' && quote == 0 && depth == 0) {
+			if item := strings.TrimSpace(list[start:i]); item != "" {
+				items = append(items, parseConfigScalar(item))
+			}
+			start = i + 1
+			continue
+		}
+		switch c := list[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '\\':
+			i++
+		case strings.IndexByte("([{", c) >= 0:
+			depth++
+		case strings.IndexByte(")]}", c) >= 0 && depth > 0:
+			depth--
+		}
+	}
+	return items
+}
+
+// parseConfigScalar parses a single TOML or YAML value, removing surrounding quotes or a trailing comment.
+func parseConfigScalar(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return value[1 : len(value)-1]
+	}
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	if comment := strings.Index(value, " #"); comment >= 0 {
+		value = strings.TrimSpace(value[:comment])
+	}
+	return value
+}
+
+// parseJSONConfig parses a JSON configuration file. Options are applied in alphabetical order of their keys.
+//
+// Parameters:
+//
+//	contents - The contents of the configuration file.
+//
+// Returns:
+//
+//	[]configValue - The options set by the file.
+//	error - An error object if the file is not a valid JSON object, otherwise nil.
+func parseJSONConfig(contents string) ([]configValue, error) {
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(contents), &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var values []configValue
+	for _, key := range keys {
+		items, ok := raw[key].([]any)
+		if !ok {
+			items = []any{raw[key]}
+		}
+		value := configValue{key: key}
+		for _, item := range items {
+			switch item := item.(type) {
+			case string:
+				value.values = append(value.values, item)
+			case bool, float64:
+				value.values = append(value.values, fmt.Sprint(item))
+			default:
+				return nil, fmt.Errorf("invalid value for %s: %v", key, item)
+			}
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// applyConfigValue applies an option read from the configuration to the options, through the flag
+// with the same long name. Flags that take no value are turned on by a true value (`true`, `yes`, `on`
+// or `1`), and off by a false one. The values of a repeated flag replace the ones set before, so the
+// environment overrides the configuration file.
+//
+// Parameters:
+//
+//	opts - The options to apply the value to.
+//	flags - The flags that can be set by the configuration.
+//	value - The option to apply.
+//
+// Returns:
+//
+//	error - An error object if the option is unknown or its value is invalid, otherwise nil.
+func applyConfigValue(opts *Options, flags []flagDef, value configValue) error {
+	name := strings.ReplaceAll(value.key, "_", "-")
+	flag := findFlag(flags, name, false)
+	if flag == nil {
+		return fmt.Errorf("unknown option: %s", value.key)
+	}
+	if len(value.values) > 1 && !flag.repeat {
+		return fmt.Errorf("option %s does not take a list", name)
+	}
+
+	if flag.reset != nil {
+		flag.reset(opts)
+	}
+	for _, item := range value.values {
+		if flag.value != "" {
+			if err := applyFlag(flag, opts, item); err != nil {
+				return err
+			}
+			continue
+		}
+		switch strings.ToLower(item) {
+		case "true", "yes", "on", "1":
+			if err := applyFlag(flag, opts, ""); err != nil {
+				return err
+			}
+		case "false", "no", "off", "0":
+			if flag.unset != nil {
+				flag.unset(opts)
+			}
+		default:
+			return fmt.Errorf("invalid value for %s: %s (expected true or false)", name, item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"toml", "# defaults\ncharset = \"ascii\"\ntrailing_slash = true\nroot-path = 'project'\nexclude = [\".git\", \"re:^node_modules$\"]\nsummary = false\n"},
+		{"toml table", "[treelike]\ncharset = \"ascii\"\ntrailing-slash = true\nroot-path = \"project\"\nexclude = [\".git\", \"node_modules\"]\n"},
+		{"yaml", "---\ncharset: ascii # team default\ntrailing-slash: yes\nroot-path: project\nexclude:\n  - .git\n  - re:^node_modules$\n"},
+		{"json", "{\"charset\": \"ascii\", \"trailing-slash\": true, \"root_path\": \"project\", \"exclude\": [\".git\", \"re:^node_modules$\"], \"summary\": false}"},
+	}
+
+	for _, test := range tests {
+		values, err := parseConfig(test.contents)
+		if err != nil {
+			t.Errorf("parseConfig(%s) failed: %v", test.name, err)
+			continue
+		}
+		opts := DefaultOptions()
+		for _, value := range values {
+			if err := applyConfigValue(opts, optionFlags, value); err != nil {
+				t.Errorf("applyConfigValue(%s, %s) failed: %v", test.name, value.key, err)
+			}
+		}
+		if opts.charset != "ascii" || !opts.trailingSlash || opts.rootPath != "project" || opts.summary || len(opts.excludes) != 2 {
+			t.Errorf("parseConfig(%s) did not set the expected options: %+v", test.name, opts)
+		}
+	}
+}
+
+func TestApplyConfigValueErrors(t *testing.T) {
+	tests := []struct {
+		value    configValue
+		expected string
+	}{
+		{configValue{key: "colour", values: []string{"red"}}, "unknown option: colour"},
+		{configValue{key: "charset", values: []string{"latin1"}}, "invalid value for --charset: latin1 (valid values: utf-8, ascii)"},
+		{configValue{key: "charset", values: []string{"ascii", "utf-8"}}, "option charset does not take a list"},
+		{configValue{key: "summary", values: []string{"maybe"}}, "invalid value for summary: maybe (expected true or false)"},
+	}
+
+	for _, test := range tests {
+		err := applyConfigValue(DefaultOptions(), optionFlags, test.value)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("applyConfigValue(%s) error\n actual = %v\nwant   = %q", test.value.key, err, test.expected)
+		}
+	}
+
+	for _, contents := range []string{"[table]\n", "charset = \"ascii\"\n[[treelike]]\n", "- .git\n", "charset: ascii\n  - .git\n", "exclude:\n  - .git\ncharset: ascii\n  - .svn\n"} {
+		lines := strings.Count(contents, "\n")
+		if _, err := parseConfig(contents); err == nil || !strings.Contains(err.Error(), fmt.Sprintf("line %d", lines)) {
+			t.Errorf("parseConfig(%q) expected an error on line %d, got %v", contents, lines, err)
+		}
+	}
+}
+
+func TestSplitConfigList(t *testing.T) {
+	tests := []struct {
+		list     string
+		expected []string
+	}{
+		{"*.go,*.md", []string{"*.go", "*.md"}},
+		{" .git , , node_modules ", []string{".git", "node_modules"}},
+		{"re:a{1,3}", []string{"re:a{1,3}"}},
+		{"re:^[a,b]+$,re:(x|y),z", []string{"re:^[a,b]+$", "re:(x|y)", "z"}},
+		{`"a,b", 'c,d', e`, []string{"a,b", "c,d", "e"}},
+		{`"re:\",x", y`, []string{`re:",x`, "y"}},
+		{`re:a\,b,c`, []string{`re:a\,b`, "c"}},
+	}
+
+	for _, test := range tests {
+		actual := splitConfigList(test.list)
+		if !slices.Equal(actual, test.expected) {
+			t.Errorf("splitConfigList(%q)\nactual = %q\nwant   = %q", test.list, actual, test.expected)
+		}
+	}
+}
+
+func TestConfigListsWithCommas(t *testing.T) {
+	values, err := parseConfig("include = [\"re:^a{1,3}$\", \"re:^[b,c]$\"]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TREELIKE_EXCLUDE", "re:^x{2,}$")
+	values = append(values, envConfig(optionFlags)...)
+
+	opts := DefaultOptions()
+	for _, value := range values {
+		if err := applyConfigValue(opts, optionFlags, value); err != nil {
+			t.Fatalf("applyConfigValue(%s) failed: %v", value.key, err)
+		}
+	}
+	root := mustParseInput(t, "aa\nb\nc\nxxx\nd\n", opts)
+	filterTree(root, opts)
+	var names []string
+	for _, child := range root.children {
+		names = append(names, child.name)
+	}
+	expected := []string{"aa", "b", "c"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("patterns with commas\nactual = %q\nwant   = %q", names, expected)
+	}
+}
+
+func TestConfigOverrides(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("TREELIKE_TRAILING_SLASH", "true")
+	t.Setenv("TREELIKE_NO_ROOT_DOT", "true")
+	t.Setenv("TREELIKE_EXCLUDE", ".git,node_modules")
+
+	tests := []struct {
+		args  []string
+		check func(opts *Options) bool
+	}{
+		{[]string{}, func(opts *Options) bool { return opts.trailingSlash && !opts.rootDot && len(opts.excludes) == 2 }},
+		{[]string{"--no-trailing-slash"}, func(opts *Options) bool { return !opts.trailingSlash }},
+		{[]string{"--root-dot"}, func(opts *Options) bool { return opts.rootDot }},
+		{[]string{"-X", "dist"}, func(opts *Options) bool {
+			return len(opts.excludes) == 1 && opts.excludes[0].matchString("dist")
+		}},
+		{[]string{"-X", "dist", "--exclude", "build"}, func(opts *Options) bool { return len(opts.excludes) == 2 }},
+		{[]string{"--no-trailing-slash", "-s"}, func(opts *Options) bool { return opts.trailingSlash }},
+	}
+
+	for _, test := range tests {
+		opts, err := configuredOptions(test.args, optionFlags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := parseArgs(opts, test.args, mainFlags()); err != nil {
+			t.Errorf("parseArgs(%q) failed: %v", test.args, err)
+			continue
+		}
+		if !test.check(opts) {
+			t.Errorf("parseArgs(%q) did not override the configuration: %+v", test.args, opts)
+		}
+	}
+
+	file, err := parseConfig("summary = true\nexclude = [\".git\"]\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultOptions()
+	for _, value := range append(file, configValue{key: "summary", values: []string{"false"}}, configValue{key: "exclude", values: []string{"a", "b"}}) {
+		if err := applyConfigValue(opts, optionFlags, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if opts.summary || len(opts.excludes) != 2 {
+		t.Errorf("applyConfigValue() did not override the configuration file: %+v", opts)
+	}
+}
+
+func TestEnvConfig(t *testing.T) {
+	t.Setenv("TREELIKE_CHARSET", "ascii")
+	t.Setenv("TREELIKE_NO_ROOT_DOT", "1")
+	t.Setenv("TREELIKE_INCLUDE", "*.go,*.md")
+
+	opts := DefaultOptions()
+	for _, value := range envConfig(optionFlags) {
+		if err := applyConfigValue(opts, optionFlags, value); err != nil {
+			t.Errorf("applyConfigValue(%s) failed: %v", value.key, err)
+		}
+	}
+	if opts.charset != "ascii" || opts.rootDot || len(opts.includes) != 2 {
+		t.Errorf("envConfig() did not set the expected options: %+v", opts)
+	}
+}
@@ -11,6 +11,7 @@ const (
 	UTF8_LAST_CHILD string = "└── "
 	UTF8_DIRECTORY  string = "│   "
 	UTF8_EMPTY      string = "    "
+	UTF8_ELLIPSIS   string = "…"
 )
 
 const (
@@ -18,4 +19,70 @@ const (
 	ASCII_LAST_CHILD string = "`-- "
 	ASCII_DIRECTORY  string = "|   "
 	ASCII_EMPTY      string = "    "
+	ASCII_ELLIPSIS   string = "..."
+)
+
+// EMPTY_NAME_PLACEHOLDER is the name displayed for lines with only whitespace, with `--empty-names placeholder`.
+const EMPTY_NAME_PLACEHOLDER string = "(empty)"
+
+const (
+	// info string of the fenced blocks rendered by `treelike docs`
+	DOCS_SOURCE_TAG string = "treelike"
+	// info string of the fenced blocks `treelike docs` writes the output into
+	DOCS_OUTPUT_TAG string = "treelike-output"
+)
+
+// NodeKind describes whether a node is a file or a directory.
+type NodeKind int
+
+const (
+	// inferred from the node's children: directory if it has any, file otherwise
+	KIND_AUTO NodeKind = iota
+	KIND_FILE
+	KIND_DIR
+)
+
+// parseEventKind identifies an event emitted by the streaming parser.
+type parseEventKind int
+
+const (
+	// a node was read; its children have not been read yet
+	EVENT_ENTER parseEventKind = iota
+	// all the descendants of a node were read
+	EVENT_LEAVE
+)
+
+// diffStatus describes how a node changed between two trees.
+type diffStatus int
+
+const (
+	DIFF_UNCHANGED diffStatus = iota
+	DIFF_ADDED
+	DIFF_REMOVED
+	DIFF_MOVED
+)
+
+const (
+	DIFF_MARKER_UNCHANGED   string = "  "
+	DIFF_MARKER_ADDED       string = "+ "
+	DIFF_MARKER_REMOVED     string = "- "
+	UTF8_DIFF_MARKER_MOVED  string = "→ "
+	ASCII_DIFF_MARKER_MOVED string = "> "
+)
+
+const (
+	ANSI_RESET  string = "\x1b[0m"
+	ANSI_RED    string = "\x1b[31m"
+	ANSI_GREEN  string = "\x1b[32m"
+	ANSI_YELLOW string = "\x1b[33m"
+	ANSI_CLEAR  string = "\x1b[H\x1b[2J"
+)
+
+const (
+	ANSI_REVERSE     string = "\x1b[7m"
+	ANSI_CLEAR_LINE  string = "\x1b[K"
+	ANSI_ALT_SCREEN  string = "\x1b[?1049h"
+	ANSI_MAIN_SCREEN string = "\x1b[?1049l"
+	ANSI_HIDE_CURSOR string = "\x1b[?25l"
+	ANSI_SHOW_CURSOR string = "\x1b[?25h"
 )
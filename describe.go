@@ -1,13 +1,16 @@
 package main
 
 import (
+	"bufio"
+	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 )
 
 // describeTree generates a string representation of the tree structure starting from the given node.
-// It recursively traverses the tree and collects lines representing each node and its children.
-// The function ensures that blank lines are removed from the final output.
+// The tree is rendered with renderTree, and lines are separated by the line ending of the system.
 //
 // Parameters:
 //
@@ -18,26 +21,150 @@ import (
 //
 //	string - A string representation of the tree structure.
 func describeTree(node *Node, opts *Options) string {
-	lines := []string{getTreeLine(node, opts)}
-	LE := getLE()
+	var builder strings.Builder
+	renderTree(&builder, node, opts)
+	return strings.TrimSuffix(builder.String(), getLE())
+}
+
+// treeRenderer holds the state of renderTree while it walks the tree.
+type treeRenderer struct {
+	w    *bufio.Writer
+	opts *Options
+	le   string
+	// tree drawing characters of the charset
+	child, lastChild, directory, empty string
+	// prefix of the lines of the current level, made of the directory and empty characters of each ancestor
+	prefix []byte
+	// number of lines written
+	lines int
+}
 
+// renderTree writes the tree structure starting from the given node to w, one line per node, each
+// followed by the line ending of the system. The tree is rendered in a single pass: the prefix of each
+// level and the path of each parent are carried down the recursion, instead of being rebuilt from the
+// ancestors of every node, so rendering large trees takes time and memory proportional to the output.
+//
+// Parameters:
+//
+//	w - The writer to write the lines to.
+//	node - The root node of the tree to render.
+//	opts - A pointer to an Options struct that specifies formatting options.
+//
+// Returns:
+//
+//	int - The number of lines written.
+//	error - An error object if writing failed, otherwise nil.
+func renderTree(w io.Writer, node *Node, opts *Options) (int, error) {
+	r := &treeRenderer{w: bufio.NewWriter(w), opts: opts, le: getLE()}
+	r.child, r.lastChild, r.directory, r.empty = getPrefixes(opts)
+
+	r.writeLine(getTreeLine(node, opts))
+	top := node.parent == nil && !opts.rootDot
+	if node.parent != nil {
+		r.prefix = append(r.prefix, getChildPrefix(node, opts)...)
+	}
+	parentPath := ""
+	if opts.fullPath {
+		parentPath = getPathPrefix(node)
+	}
+	r.renderChildren(node, top, parentPath)
+
+	return r.lines, r.w.Flush()
+}
+
+// renderChildren writes the lines of the children of a node and their descendants.
+//
+// Parameters:
+//
+//	node - The node whose children to render.
+//	top - True if the children are top-level nodes displayed without a root, which have no tree drawing
+//	      characters.
+//	parentPath - The full path of the node, ending with a slash, if the fullPath option is enabled.
+func (r *treeRenderer) renderChildren(node *Node, top bool, parentPath string) {
 	for _, child := range node.children {
-		next := describeTree(child, opts)
-		for _, line := range strings.Split(next, LE) {
-			if strings.TrimSpace(line) != "" {
-				lines = append(lines, line)
-			}
+		r.renderNode(child, isLastChild(child), top, parentPath)
+	}
+}
+
+// renderNode writes the line of a node, followed by the lines of its descendants.
+//
+// Parameters:
+//
+//	node - The node to render.
+//	last - True if the node is the last child of its parent.
+//	top - True if the node is a top-level node displayed without a root, which has no tree drawing
+//	      characters.
+//	parentPath - The full path of the node's parent, ending with a slash, if the fullPath option is enabled.
+func (r *treeRenderer) renderNode(node *Node, last bool, top bool, parentPath string) {
+	name := formatName(node, r.opts, parentPath)
+
+	size := len(r.prefix)
+	if top {
+		r.writeLine(name)
+	} else {
+		connector := r.child
+		if last {
+			connector = r.lastChild
+		}
+		r.w.Write(r.prefix)
+		r.w.WriteString(connector)
+		r.w.WriteString(name)
+		r.w.WriteString(r.le)
+		r.lines++
+		if last {
+			r.prefix = append(r.prefix, r.empty...)
+		} else {
+			r.prefix = append(r.prefix, r.directory...)
 		}
 	}
 
-	var nonBlankLines []string
-	for _, line := range lines {
-		if strings.TrimSpace(line) != "" {
-			nonBlankLines = append(nonBlankLines, line)
+	if len(node.children) > 0 {
+		childPath := ""
+		if r.opts.fullPath {
+			childPath = parentPath + getPathSegment(node)
 		}
+		r.renderChildren(node, false, childPath)
 	}
+	r.prefix = r.prefix[:size]
+}
+
+// writeLine writes a line followed by the line ending, unless the line is blank, as lines without tree
+// drawing characters can be.
+func (r *treeRenderer) writeLine(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	r.w.WriteString(line)
+	r.w.WriteString(r.le)
+	r.lines++
+}
 
-	return strings.Join(nonBlankLines, LE)
+// getChildPrefix returns the prefix of the lines of a node's children: the directory or empty characters
+// of the node and each of its ancestors below the root. If the rootDot option is disabled, the characters
+// of the top-level ancestor are left out, as they are for its line.
+//
+// Parameters:
+//
+//	node - The node whose children to get the prefix for.
+//	opts - A pointer to an Options struct that specifies formatting options.
+//
+// Returns:
+//
+//	string - The prefix of the lines of the node's children.
+func getChildPrefix(node *Node, opts *Options) string {
+	_, _, DIRECTORY, EMPTY := getPrefixes(opts)
+	str := ""
+	for current := node; current != nil && current.parent != nil; current = current.parent {
+		if isLastChild(current) {
+			str = EMPTY + str
+		} else {
+			str = DIRECTORY + str
+		}
+	}
+	if opts.rootDot {
+		return str
+	}
+	return removePrefix(str, opts)
 }
 
 // getPrefixes returns the appropriate tree drawing characters based on the specified charset in options.
@@ -124,9 +251,10 @@ func getTreeLine(node *Node, opts *Options) string {
 	return removePrefix(str, opts)
 }
 
-// getName generates the name of the node, optionally appending a trailing slash if the node has children
+// getName generates the name of the node, optionally appending a trailing slash if the node is a directory
 // and the trailingSlash option is enabled. If the fullPath option is enabled, it recursively constructs
-// the full path of the node.
+// the full path of the node. If the attributes option is enabled, the node's attributes are appended
+// after the name.
 //
 // Parameters:
 //
@@ -137,27 +265,136 @@ func getTreeLine(node *Node, opts *Options) string {
 //
 //	string - The generated name of the node.
 func getName(node *Node, opts *Options) string {
+	parentPath := ""
+	if opts.fullPath && node.parent != nil {
+		parentPath = getPathPrefix(node.parent)
+	}
+	return formatName(node, opts, parentPath)
+}
+
+// formatName generates the name of the node like getName, with the full path of its parent given rather
+// than built from its ancestors.
+//
+// Parameters:
+//
+//	node - The node for which to generate the name.
+//	opts - A pointer to an Options struct that specifies formatting options.
+//	parentPath - The full path of the node's parent, ending with a slash, or an empty string if the
+//	             fullPath option is disabled.
+//
+// Returns:
+//
+//	string - The generated name of the node.
+func formatName(node *Node, opts *Options, parentPath string) string {
+	if node.hidden > 0 {
+		return getElisionName(node, opts)
+	}
+
 	var chunks strings.Builder
 
-	chunks.WriteString(node.name)
+	chunks.WriteString(joinPath(parentPath, node.name))
 
-	if opts.trailingSlash && len(node.children) > 0 && node.name[len(node.name)-1] != '/' {
+	if opts.trailingSlash && node.isDir() && !strings.HasSuffix(node.name, "/") {
 		chunks.WriteString("/")
 	}
 
-	str := chunks.String()
+	if opts.attributes && len(node.attrs) > 0 {
+		chunks.WriteString(" " + formatAttributes(node.attrs))
+	}
 
-	if opts.fullPath && node.parent != nil {
-		newOpts := DefaultOptions()
-		newOpts.charset = opts.charset
-		newOpts.fullPath = opts.fullPath
-		newOpts.rootPath = opts.rootPath
-		newOpts.rootDot = opts.rootDot
-		newOpts.trailingSlash = true
-		str = getName(node.parent, newOpts) + str
+	return chunks.String()
+}
+
+// getPathPrefix returns the full path of a node, with a trailing slash, as it is prefixed to the names of
+// its children when the fullPath option is enabled.
+//
+// Parameters:
+//
+//	node - The node to get the path of.
+//
+// Returns:
+//
+//	string - The full path of the node, ending with a slash.
+func getPathPrefix(node *Node) string {
+	if node.parent == nil {
+		if strings.HasSuffix(node.name, "/") {
+			return node.name
+		}
+		return node.name + "/"
 	}
+	return getPathPrefix(node.parent) + getPathSegment(node)
+}
 
-	return str
+// getPathSegment returns the name of a node as a segment of the paths of its descendants, without leading
+// or trailing slashes, followed by a single slash. A name made only of slashes adds no segment, so paths
+// never contain empty segments such as `a//c`.
+func getPathSegment(node *Node) string {
+	name := strings.Trim(node.name, "/")
+	if name == "" {
+		return ""
+	}
+	return name + "/"
+}
+
+// joinPath appends a name to the path of its parent. The leading slashes of the name are removed when
+// there is a parent path, as it already ends with one.
+//
+// Parameters:
+//
+//	parentPath - The full path of the parent, ending with a slash, or an empty string.
+//	name - The name to append.
+//
+// Returns:
+//
+//	string - The joined path.
+func joinPath(parentPath, name string) string {
+	if parentPath == "" {
+		return name
+	}
+	return parentPath + strings.TrimLeft(name, "/")
+}
+
+// getElisionName generates the name of an elision node, such as `… (12 more)`, which stands in
+// for the descendants hidden by the maxDepth option.
+//
+// Parameters:
+//
+//	node - The elision node.
+//	opts - A pointer to an Options struct that specifies the charset.
+//
+// Returns:
+//
+//	string - The name of the elision node.
+func getElisionName(node *Node, opts *Options) string {
+	ellipsis := UTF8_ELLIPSIS
+	if opts.charset == "ascii" {
+		ellipsis = ASCII_ELLIPSIS
+	}
+	return fmt.Sprintf("%s (%d more)", ellipsis, node.hidden)
+}
+
+// formatAttributes formats a map of attributes as a `{key=value, ...}` block, with keys sorted
+// alphabetically so the output is stable between runs.
+//
+// Parameters:
+//
+//	attrs - The attributes to format.
+//
+// Returns:
+//
+//	string - The formatted attribute block.
+func formatAttributes(attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+attrs[key])
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
 }
 
 // isLastChild checks if the given node is the last child of its parent.
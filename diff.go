@@ -0,0 +1,378 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// treeDiff is a unified tree combining two trees, where each node is marked with how it changed.
+type treeDiff struct {
+	// root of the unified tree
+	root *Node
+	// change status of each node in the unified tree
+	status map[*Node]diffStatus
+	// previous path of each moved node
+	movedFrom map[*Node]string
+	// nodes of the old tree that each unified node was built from, if any
+	oldNodes map[*Node]*Node
+	// nodes of the new tree that each unified node was built from, if any
+	newNodes map[*Node]*Node
+}
+
+// diffFlags returns the flags of the diff command.
+//
+// Parameters:
+//
+//	color - Set to true when the --color flag is given.
+//
+// Returns:
+//
+//	[]flagDef - The flags of the diff command.
+func diffFlags(color *bool) []flagDef {
+	return slices.Concat(commonFlags, optionFlags, []flagDef{
+		{long: "color", usage: "Color added, removed and moved nodes", apply: func(opts *Options, value string) error {
+			*color = true
+			return nil
+		}},
+	})
+}
+
+// diffHelpText generates and returns a strings.Builder containing the help text for the diff command.
+//
+// Returns:
+//
+//	strings.Builder - A builder containing the formatted help text.
+func diffHelpText() strings.Builder {
+	LE := getLE()
+	var builder strings.Builder
+	builder.WriteString("Usage: treelike diff [OPTIONS] OLD NEW" + LE)
+	builder.WriteString("Prints a unified tree showing the differences between the OLD and NEW tree files." + LE)
+	builder.WriteString("Use `-` as OLD or NEW to read that tree from stdin." + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Added nodes are marked with `+`, removed nodes with `-` and moved nodes with `→`." + LE)
+	builder.WriteString("Exits with 0 if the trees are the same, 1 if they differ, and 2 on errors. With" + LE)
+	builder.WriteString("--max-depth, changes below the limit are not shown, but still make the trees differ." + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Options:" + LE)
+	builder.WriteString(flagsHelpText(diffFlags(nil)))
+	return builder
+}
+
+// runDiff runs the diff command with the given arguments, and returns the exit code.
+//
+// Parameters:
+//
+//	args - The command-line arguments following the `diff` command.
+//
+// Returns:
+//
+//	int - 0 if the trees are the same, 1 if they differ, and 2 on errors.
+func runDiff(args []string) int {
+	color := false
+	opts, err := configuredOptions(args, optionFlags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "treelike: %s\n", err)
+		return 2
+	}
+	positional, err := parseArgs(opts, args, diffFlags(&color))
+	if err == nil {
+		err = checkDiffArgs(positional)
+	}
+	if err != nil {
+		handleArgsError(err, diffHelpText())
+	}
+
+	oldRoot, err := readTreeSource(positional[0], opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	newRoot, err := readTreeSource(positional[1], opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	diff := diffInputs(oldRoot, newRoot, opts)
+	fmt.Println(describeDiff(diff, opts, color))
+	if diff.changed() {
+		return 1
+	}
+	return 0
+}
+
+// checkDiffArgs checks the positional arguments of the diff command. Stdin can only be read once, so it
+// can not be used for both trees.
+//
+// Parameters:
+//
+//	positional - The positional arguments following the `diff` command.
+//
+// Returns:
+//
+//	error - An error object describing the invalid arguments, otherwise nil.
+func checkDiffArgs(positional []string) error {
+	if len(positional) != 2 {
+		return fmt.Errorf("expected OLD and NEW trees, got %d arguments", len(positional))
+	}
+	if positional[0] == "-" && positional[1] == "-" {
+		return errors.New("only one of OLD and NEW can be read from stdin")
+	}
+	return nil
+}
+
+// diffInputs transforms two parsed trees with the given options, and builds their unified tree. The depth
+// limit is applied to the unified tree only, so changes below it are still detected.
+//
+// Parameters:
+//
+//	oldRoot - The root node of the old tree.
+//	newRoot - The root node of the new tree.
+//	opts - A pointer to an Options struct that specifies the transformations.
+//
+// Returns:
+//
+//	*treeDiff - The unified tree.
+func diffInputs(oldRoot, newRoot *Node, opts *Options) *treeDiff {
+	treeOpts := *opts
+	treeOpts.maxDepth = 0
+	transformTree(oldRoot, &treeOpts)
+	transformTree(newRoot, &treeOpts)
+	diff := diffTrees(oldRoot, newRoot)
+	limitDepth(diff.root, opts.maxDepth)
+	return diff
+}
+
+// diffTrees builds a unified tree from two trees. Children are matched by name under the same parent,
+// so reordering siblings is not considered a change. A removed subtree whose name matches exactly one
+// added subtree elsewhere is considered moved, and is shown only at its new location.
+//
+// Parameters:
+//
+//	oldRoot - The root node of the old tree.
+//	newRoot - The root node of the new tree.
+//
+// Returns:
+//
+//	*treeDiff - The unified tree.
+func diffTrees(oldRoot, newRoot *Node) *treeDiff {
+	d := &treeDiff{
+		status:    map[*Node]diffStatus{},
+		movedFrom: map[*Node]string{},
+		oldNodes:  map[*Node]*Node{},
+		newNodes:  map[*Node]*Node{},
+	}
+	d.root = &Node{name: newRoot.name, depth: 0, children: []*Node{}, parent: nil}
+	d.diffChildren(d.root, oldRoot, newRoot)
+	d.detectMoves()
+	return d
+}
+
+// diffChildren adds the children of the old and new nodes to the given unified node. Matched children are
+// listed in the order of the new tree, and each unmatched old child is listed before the first matched
+// child that followed it in the old tree.
+//
+// Parameters:
+//
+//	parent - The unified node to add children to.
+//	oldNode - The node of the old tree, or nil if the parent was added.
+//	newNode - The node of the new tree, or nil if the parent was removed.
+func (d *treeDiff) diffChildren(parent, oldNode, newNode *Node) {
+	var oldChildren, newChildren []*Node
+	if oldNode != nil {
+		oldChildren = oldNode.children
+	}
+	if newNode != nil {
+		newChildren = newNode.children
+	}
+
+	used := make([]bool, len(oldChildren))
+	matches := make([]int, len(newChildren))
+	for i, newChild := range newChildren {
+		matches[i] = -1
+		for j, oldChild := range oldChildren {
+			if !used[j] && nodeKey(oldChild) == nodeKey(newChild) {
+				used[j] = true
+				matches[i] = j
+				break
+			}
+		}
+	}
+
+	next := 0
+	for i, newChild := range newChildren {
+		if matches[i] < 0 {
+			d.addNode(parent, nil, newChild, DIFF_ADDED)
+			continue
+		}
+		for ; next < matches[i]; next++ {
+			if !used[next] {
+				d.addNode(parent, oldChildren[next], nil, DIFF_REMOVED)
+			}
+		}
+		next = max(next, matches[i]+1)
+		d.addNode(parent, oldChildren[matches[i]], newChild, DIFF_UNCHANGED)
+	}
+	for ; next < len(oldChildren); next++ {
+		if !used[next] {
+			d.addNode(parent, oldChildren[next], nil, DIFF_REMOVED)
+		}
+	}
+}
+
+// addNode adds a unified node built from the given old and new nodes to the parent, along with its children.
+//
+// Parameters:
+//
+//	parent - The unified node to add the node to.
+//	oldNode - The node of the old tree, or nil if the node was added.
+//	newNode - The node of the new tree, or nil if the node was removed.
+//	status - The change status of the node.
+func (d *treeDiff) addNode(parent, oldNode, newNode *Node, status diffStatus) {
+	source := newNode
+	if source == nil {
+		source = oldNode
+	}
+	node := &Node{name: source.name, depth: parent.depth + 1, children: []*Node{}, parent: parent, attrs: source.attrs, kind: source.kind, hidden: source.hidden}
+	parent.children = append(parent.children, node)
+	d.status[node] = status
+	d.oldNodes[node] = oldNode
+	d.newNodes[node] = newNode
+	d.diffChildren(node, oldNode, newNode)
+}
+
+// detectMoves pairs removed subtrees with added subtrees of the same name and kind. When a name is
+// removed and added exactly once, the added node is marked as moved, the removed node is dropped,
+// and the moved subtree is diffed against its previous contents. Whole subtrees are paired first, then
+// the leaves left inside added or removed subtrees, so a file moved into a new directory is also found.
+func (d *treeDiff) detectMoves() {
+	d.pairMoves(func(node *Node) bool {
+		return d.status[node.parent] != d.status[node]
+	})
+	d.pairMoves(func(node *Node) bool {
+		return len(node.children) == 0
+	})
+}
+
+// pairMoves marks as moved each added node that has exactly one removed node with the same name and
+// kind, among the nodes accepted by the given function.
+//
+// Parameters:
+//
+//	candidate - Reports whether an added or removed node may be paired.
+func (d *treeDiff) pairMoves(candidate func(node *Node) bool) {
+	removed := map[string][]*Node{}
+	added := map[string][]*Node{}
+	d.walk(d.root, func(node *Node) {
+		status := d.status[node]
+		if node.parent == nil || !candidate(node) {
+			return
+		}
+		switch status {
+		case DIFF_REMOVED:
+			removed[nodeKey(node)] = append(removed[nodeKey(node)], node)
+		case DIFF_ADDED:
+			added[nodeKey(node)] = append(added[nodeKey(node)], node)
+		}
+	})
+
+	for key, removedNodes := range removed {
+		addedNodes := added[key]
+		if len(removedNodes) != 1 || len(addedNodes) != 1 {
+			continue
+		}
+		from, to := removedNodes[0], addedNodes[0]
+		if d.oldNodes[from].isDir() != d.newNodes[to].isDir() {
+			continue
+		}
+		d.movedFrom[to] = nodePath(from)
+		d.status[to] = DIFF_MOVED
+		from.parent.children = slices.DeleteFunc(from.parent.children, func(child *Node) bool { return child == from })
+		to.children = []*Node{}
+		d.diffChildren(to, d.oldNodes[from], d.newNodes[to])
+	}
+}
+
+// walk calls fn for the given node and each of its descendants, in display order.
+func (d *treeDiff) walk(node *Node, fn func(*Node)) {
+	fn(node)
+	for _, child := range slices.Clone(node.children) {
+		d.walk(child, fn)
+	}
+}
+
+// changed reports whether any node in the unified tree was added, removed or moved.
+func (d *treeDiff) changed() bool {
+	for _, status := range d.status {
+		if status != DIFF_UNCHANGED {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeKey returns the name used to match nodes between trees, ignoring a trailing slash.
+func nodeKey(node *Node) string {
+	return strings.TrimSuffix(node.name, "/")
+}
+
+// describeDiff generates a string representation of a unified tree. Each line is prefixed with a marker
+// column showing how the node changed, and moved nodes are followed by their previous path.
+//
+// Parameters:
+//
+//	d - The unified tree to describe.
+//	opts - A pointer to an Options struct that specifies formatting options.
+//	color - Whether to color changed lines with ANSI escape codes.
+//
+// Returns:
+//
+//	string - A string representation of the unified tree.
+func describeDiff(d *treeDiff, opts *Options, color bool) string {
+	var lines []string
+	d.walk(d.root, func(node *Node) {
+		line := getTreeLine(node, opts)
+		if strings.TrimSpace(line) == "" {
+			return
+		}
+		marker, ansi := getDiffMarker(d.status[node], opts)
+		line = marker + line
+		if from, ok := d.movedFrom[node]; ok {
+			line += " (from " + from + ")"
+		}
+		if color && ansi != "" {
+			line = ansi + line + ANSI_RESET
+		}
+		lines = append(lines, line)
+	})
+	return strings.Join(lines, getLE())
+}
+
+// getDiffMarker returns the marker and ANSI color for the given change status.
+//
+// Parameters:
+//
+//	status - The change status of a node.
+//	opts - A pointer to an Options struct that specifies the charset.
+//
+// Returns:
+//
+//	string - The marker to prefix the line with.
+//	string - The ANSI color code for the line, or an empty string for unchanged nodes.
+func getDiffMarker(status diffStatus, opts *Options) (string, string) {
+	switch status {
+	case DIFF_ADDED:
+		return DIFF_MARKER_ADDED, ANSI_GREEN
+	case DIFF_REMOVED:
+		return DIFF_MARKER_REMOVED, ANSI_RED
+	case DIFF_MOVED:
+		if opts.charset == "ascii" {
+			return ASCII_DIFF_MARKER_MOVED, ANSI_YELLOW
+		}
+		return UTF8_DIFF_MARKER_MOVED, ANSI_YELLOW
+	}
+	return DIFF_MARKER_UNCHANGED, ""
+}
@@ -0,0 +1,105 @@
+package main
+
+import "testing"
+
+func TestDiffTrees(t *testing.T) {
+	oldInput := "usr\n  local\n  bin\n    sh\n    bash\n  sbin\n    sysctl\n"
+	newInput := "usr\n  bin\n    sh\n    zsh\n  local\n    sbin\n      sysctl\n      tcpdump\n  lib\n"
+	opts := DefaultOptions()
+	diff := diffTrees(mustParseInput(t, oldInput, opts), mustParseInput(t, newInput, opts))
+
+	if !diff.changed() {
+		t.Errorf("Expected trees to differ")
+	}
+
+	result := describeDiff(diff, opts, false)
+	expected := "  .\n" +
+		"  └── usr\n" +
+		"      ├── bin\n" +
+		"      │   ├── sh\n" +
+		"+     │   ├── zsh\n" +
+		"-     │   └── bash\n" +
+		"      ├── local\n" +
+		"→     │   └── sbin (from usr/sbin)\n" +
+		"      │       ├── sysctl\n" +
+		"+     │       └── tcpdump\n" +
+		"+     └── lib"
+	if result != expected {
+		t.Errorf("describeDiff()\n actual = %q\nwant   = %q", result, expected)
+	}
+}
+
+func TestDiffTreesMoveIntoNewDirectory(t *testing.T) {
+	opts := DefaultOptions()
+	diff := diffTrees(mustParseInput(t, "src\n  a.go\n  b.go\n", opts), mustParseInput(t, "src\n  a.go\n  lib\n    b.go\n", opts))
+
+	result := describeDiff(diff, opts, false)
+	expected := "  .\n" +
+		"  └── src\n" +
+		"      ├── a.go\n" +
+		"+     └── lib\n" +
+		"→         └── b.go (from src/b.go)"
+	if result != expected {
+		t.Errorf("describeDiff()\n actual = %q\nwant   = %q", result, expected)
+	}
+}
+
+func TestDiffInputsMaxDepth(t *testing.T) {
+	opts := DefaultOptions()
+	opts.maxDepth = 1
+	diff := diffInputs(mustParseInput(t, "a\n  b\n", opts), mustParseInput(t, "a\n  c\n", opts), opts)
+
+	if !diff.changed() {
+		t.Errorf("Expected trees that differ below the max depth to differ")
+	}
+	result := describeDiff(diff, opts, false)
+	expected := "  .\n  └── a\n      └── " + UTF8_ELLIPSIS + " (2 more)"
+	if result != expected {
+		t.Errorf("describeDiff()\n actual = %q\nwant   = %q", result, expected)
+	}
+}
+
+func TestDiffTreesUnchanged(t *testing.T) {
+	opts := DefaultOptions()
+	diff := diffTrees(mustParseInput(t, "a\n  b\n  c\n", opts), mustParseInput(t, "a\n  c\n  b\n", opts))
+
+	if diff.changed() {
+		t.Errorf("Expected reordered siblings to be unchanged")
+	}
+}
+
+func TestDiffTreesColor(t *testing.T) {
+	opts := DefaultOptions()
+	opts.rootDot = false
+	diff := diffTrees(mustParseInput(t, "a\n", opts), mustParseInput(t, "b\n", opts))
+
+	result := describeDiff(diff, opts, true)
+	expected := ANSI_GREEN + "+ b" + ANSI_RESET + "\n" + ANSI_RED + "- a" + ANSI_RESET
+	if result != expected {
+		t.Errorf("describeDiff()\n actual = %q\nwant   = %q", result, expected)
+	}
+}
+
+func TestCheckDiffArgs(t *testing.T) {
+	tests := []struct {
+		positional []string
+		expected   string
+	}{
+		{[]string{"old.txt", "new.txt"}, ""},
+		{[]string{"-", "new.txt"}, ""},
+		{[]string{"old.txt", "-"}, ""},
+		{[]string{"-", "-"}, "only one of OLD and NEW can be read from stdin"},
+		{[]string{"old.txt"}, "expected OLD and NEW trees, got 1 arguments"},
+	}
+
+	for _, test := range tests {
+		err := checkDiffArgs(test.positional)
+		actual := ""
+		if err != nil {
+			actual = err.Error()
+		}
+		if actual != test.expected {
+			t.Errorf("checkDiffArgs(%q)\nactual = %q\nwant   = %q", test.positional, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// docsHelpText generates and returns a strings.Builder containing the help text for the docs command.
+//
+// Returns:
+//
+//	strings.Builder - A builder containing the formatted help text.
+func docsHelpText() strings.Builder {
+	LE := getLE()
+	var builder strings.Builder
+	builder.WriteString("Usage: treelike docs [--check] PATH..." + LE)
+	builder.WriteString("Updates the rendered trees in Markdown files. PATH is a Markdown file, or a directory" + LE)
+	builder.WriteString("to search for `.md` files." + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Each fenced block tagged `treelike` is rendered, and the output is written into the" + LE)
+	builder.WriteString("fenced block tagged `treelike-output` that follows it, which is added if it is missing." + LE)
+	builder.WriteString("Other fenced blocks are never changed." + LE)
+	builder.WriteString("Options can be passed after the tag, e.g. ```treelike -c ascii -s" + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Options:" + LE)
+	builder.WriteString(flagsHelpText(docsFlags(nil)))
+	return builder
+}
+
+// docsFlags returns the flags of the docs command.
+//
+// Parameters:
+//
+//	check - Set to true when the --check flag is given.
+//
+// Returns:
+//
+//	[]flagDef - The flags of the docs command.
+func docsFlags(check *bool) []flagDef {
+	return slices.Concat(commonFlags, []flagDef{
+		{long: "check", usage: "Do not write files, exit with 1 if any of them are out of date", apply: func(opts *Options, value string) error {
+			*check = true
+			return nil
+		}},
+	})
+}
+
+// runDocs runs the docs command with the given arguments, and returns the exit code.
+//
+// Parameters:
+//
+//	args - The command-line arguments following the `docs` command.
+//
+// Returns:
+//
+//	int - 0 on success, 1 if --check is enabled and any file is out of date, and 2 on errors.
+func runDocs(args []string) int {
+	check := false
+	paths, err := parseArgs(DefaultOptions(), args, docsFlags(&check))
+	if err == nil && len(paths) == 0 {
+		err = fmt.Errorf("expected at least one PATH")
+	}
+	if err != nil {
+		handleArgsError(err, docsHelpText())
+	}
+
+	files, err := findMarkdownFiles(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	stale := false
+	for _, file := range files {
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error opening file %s: %v\n", file, err)
+			return 2
+		}
+		updated, err := updateDocs(string(contents))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error rendering %s: %v\n", file, err)
+			return 2
+		}
+		if updated == string(contents) {
+			continue
+		}
+		if check {
+			fmt.Println("stale: " + file)
+			stale = true
+			continue
+		}
+		if err := os.WriteFile(file, []byte(updated), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing file %s: %v\n", file, err)
+			return 2
+		}
+		fmt.Println("updated: " + file)
+	}
+
+	if stale {
+		return 1
+	}
+	return 0
+}
+
+// findMarkdownFiles expands the given paths into a list of Markdown files. Files are returned as-is,
+// and directories are searched recursively for files with a `.md` extension.
+//
+// Parameters:
+//
+//	paths - The files and directories to search.
+//
+// Returns:
+//
+//	[]string - The Markdown files.
+//	error - An error object if a path could not be read, otherwise nil.
+func findMarkdownFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("error opening %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(file), ".md") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error reading directory %s: %w", path, err)
+		}
+	}
+	return files, nil
+}
+
+// updateDocs renders every `treelike` fenced block in a Markdown document, and writes the output into the
+// `treelike-output` fenced block that follows it. If the next block is not tagged `treelike-output`, a new
+// block is inserted after the source block instead. Other blocks are copied as they are, with their
+// contents, so a `treelike` block shown inside another fenced block is not rendered.
+//
+// Parameters:
+//
+//	contents - The Markdown document.
+//
+// Returns:
+//
+//	string - The updated Markdown document.
+//	error - An error object if the options of a block are invalid, otherwise nil.
+func updateDocs(contents string) (string, error) {
+	lines := strings.Split(contents, "\n")
+	var out []string
+
+	for i := 0; i < len(lines); i++ {
+		fence, info, ok := parseFence(lines[i])
+		if !ok {
+			out = append(out, lines[i])
+			continue
+		}
+
+		end := findClosingFence(lines, i+1, fence)
+		if end < 0 {
+			out = append(out, lines[i:]...)
+			break
+		}
+		fields := strings.Fields(info)
+		if len(fields) == 0 || fields[0] != DOCS_SOURCE_TAG {
+			out = append(out, lines[i:end+1]...)
+			i = end
+			continue
+		}
+		source := strings.Join(lines[i+1:end], "\n")
+		out = append(out, lines[i:end+1]...)
+		rendered, err := renderDocsBlock(source, fields[1:])
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		next := end + 1
+		for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+			next++
+		}
+		outputFence, outputInfo, hasOutput := "", "", false
+		if next < len(lines) {
+			outputFence, outputInfo, hasOutput = parseFence(lines[next])
+		}
+		outputEnd := -1
+		if hasOutput && outputInfo == DOCS_OUTPUT_TAG {
+			outputEnd = findClosingFence(lines, next+1, outputFence)
+		}
+
+		if outputEnd < 0 {
+			out = append(out, "", "```"+DOCS_OUTPUT_TAG)
+			out = append(out, rendered...)
+			out = append(out, "```")
+			i = end
+			continue
+		}
+		out = append(out, lines[end+1:next+1]...)
+		out = append(out, rendered...)
+		out = append(out, lines[outputEnd])
+		i = outputEnd
+	}
+
+	return strings.Join(out, "\n"), nil
+}
+
+// renderDocsBlock renders the source of a `treelike` fenced block with the given options.
+//
+// Parameters:
+//
+//	source - The tree source inside the block.
+//	args - The options following the `treelike` tag.
+//
+// Returns:
+//
+//	[]string - The lines of the rendered output.
+//	error - An error object if the options are invalid, otherwise nil.
+func renderDocsBlock(source string, args []string) ([]string, error) {
+	opts := DefaultOptions()
+	positional, err := parseArgs(opts, args, optionFlags)
+	if err == nil && len(positional) > 0 {
+		err = fmt.Errorf("unexpected argument: %s", positional[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	node, err := parseReader(strings.NewReader(source), opts)
+	if err != nil {
+		return nil, err
+	}
+	transformTree(node, opts)
+	output := strings.ReplaceAll(describeOutput(node, opts), LE_WIN, LE_UNIX)
+	return strings.Split(output, "\n"), nil
+}
+
+// parseFence parses a Markdown fence line, such as "```treelike -s".
+//
+// Parameters:
+//
+//	line - The line to parse.
+//
+// Returns:
+//
+//	string - The fence characters, such as "```" or "~~~~".
+//	string - The info string following the fence, trimmed.
+//	bool - True if the line is a fence, false otherwise.
+func parseFence(line string) (string, string, bool) {
+	trimmed := strings.TrimRight(strings.TrimLeft(line, " "), "\r")
+	if len(line)-len(strings.TrimLeft(line, " ")) > 3 {
+		return "", "", false
+	}
+	for _, char := range []string{"`", "~"} {
+		count := len(trimmed) - len(strings.TrimLeft(trimmed, char))
+		if count >= 3 {
+			return trimmed[:count], strings.TrimSpace(trimmed[count:]), true
+		}
+	}
+	return "", "", false
+}
+
+// findClosingFence returns the index of the line closing a fenced block, starting the search at the
+// given index, or -1 if the block is not closed.
+//
+// Parameters:
+//
+//	lines - The lines of the document.
+//	start - The index of the first line inside the block.
+//	fence - The fence characters that opened the block.
+//
+// Returns:
+//
+//	int - The index of the closing line, or -1.
+func findClosingFence(lines []string, start int, fence string) int {
+	for i := start; i < len(lines); i++ {
+		closing, info, ok := parseFence(lines[i])
+		if ok && info == "" && closing[0] == fence[0] && len(closing) >= len(fence) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestUpdateDocs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			"inserts missing output block",
+			"# Title\n\n```treelike\na\n  b\n```\n\nText\n",
+			"# Title\n\n```treelike\na\n  b\n```\n\n```treelike-output\n.\n└── a\n    └── b\n```\n\nText\n",
+		},
+		{
+			"replaces stale output block with options",
+			"```treelike -D -s\na\n  b\n```\n\n```treelike-output\nstale\n```\n",
+			"```treelike -D -s\na\n  b\n```\n\n```treelike-output\na/\n└── b\n```\n",
+		},
+		{
+			"keeps unrelated blocks",
+			"```treelike\na\n```\n```go\ncode\n```\n",
+			"```treelike\na\n```\n\n```treelike-output\n.\n└── a\n```\n```go\ncode\n```\n",
+		},
+		{
+			"keeps untagged blocks",
+			"```treelike\na\n```\n\n```\nmake\n```\n",
+			"```treelike\na\n```\n\n```treelike-output\n.\n└── a\n```\n\n```\nmake\n```\n",
+		},
+		{
+			"keeps examples inside other blocks",
+			"````markdown\n```treelike\na\n```\n\n```\nb\n```\n````\n\n```treelike\nc\n```\n",
+			"````markdown\n```treelike\na\n```\n\n```\nb\n```\n````\n\n```treelike\nc\n```\n\n```treelike-output\n.\n└── c\n```\n",
+		},
+		{
+			"keeps unterminated other blocks",
+			"~~~\n```treelike\na\n```\n",
+			"~~~\n```treelike\na\n```\n",
+		},
+		{
+			"ignores unterminated blocks",
+			"```treelike\na\n",
+			"```treelike\na\n",
+		},
+	}
+
+	for _, test := range tests {
+		result, err := updateDocs(test.input)
+		if err != nil {
+			t.Fatalf("updateDocs(%s) failed: %v", test.name, err)
+		}
+		if result != test.expected {
+			t.Errorf("updateDocs(%s)\n actual = %q\nwant   = %q", test.name, result, test.expected)
+		}
+		if again, _ := updateDocs(result); again != result {
+			t.Errorf("updateDocs(%s) is not idempotent\n actual = %q\nwant   = %q", test.name, again, result)
+		}
+	}
+}
+
+func TestUpdateDocsInvalidOptions(t *testing.T) {
+	if _, err := updateDocs("text\n```treelike --nope\na\n```\n"); err == nil || err.Error() != "line 2: unknown option: --nope" {
+		t.Errorf("updateDocs() expected an unknown option error, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Pattern matches nodes by name, path or attribute. Patterns are written as:
+//
+//	*.proto        - a glob, matched against the node name or its path from the root
+//	re:\.pb\.go$   - a regular expression, matched against the node name or its path from the root
+//	attr:owner=@web - an attribute with the given value
+//	attr:generated - an attribute with any value
+type Pattern struct {
+	// glob pattern, if the pattern is a glob
+	glob string
+	// compiled regular expression, if the pattern is a regex
+	regex *regexp.Regexp
+	// attribute key, if the pattern is an attribute pattern
+	attrKey string
+	// attribute value, if the attribute pattern has one
+	attrValue *string
+}
+
+// parsePattern compiles a pattern string into a Pattern.
+//
+// Parameters:
+//
+//	pattern - The pattern string, a glob, or a regex or attribute pattern with its `re:` or `attr:` prefix.
+//
+// Returns:
+//
+//	*Pattern - The compiled pattern.
+//	error - An error object if the pattern is invalid, otherwise nil.
+func parsePattern(pattern string) (*Pattern, error) {
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex pattern %q: %w", expr, err)
+		}
+		return &Pattern{regex: regex}, nil
+	}
+	if attr, ok := strings.CutPrefix(pattern, "attr:"); ok {
+		key, value, hasValue := strings.Cut(attr, "=")
+		if key == "" {
+			return nil, fmt.Errorf("invalid attribute pattern %q: missing key", pattern)
+		}
+		if hasValue {
+			return &Pattern{attrKey: key, attrValue: &value}, nil
+		}
+		return &Pattern{attrKey: key}, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+	return &Pattern{glob: pattern}, nil
+}
+
+// matches reports whether the pattern matches the given node.
+//
+// Parameters:
+//
+//	node - The node to match.
+//
+// Returns:
+//
+//	bool - True if the node matches the pattern, false otherwise.
+func (p *Pattern) matches(node *Node) bool {
+	if p.attrKey != "" {
+		value, ok := node.attr(p.attrKey)
+		return ok && (p.attrValue == nil || *p.attrValue == value)
+	}
+	return p.matchString(strings.TrimSuffix(node.name, "/")) || p.matchString(nodePath(node))
+}
+
+// matchString reports whether a glob or regex pattern matches the given string.
+func (p *Pattern) matchString(str string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(str)
+	}
+	matched, _ := path.Match(p.glob, str)
+	return matched
+}
+
+// nodePath returns the path of the node from the root, without the root name, e.g. `usr/bin/sh`.
+//
+// Parameters:
+//
+//	node - The node whose path to build.
+//
+// Returns:
+//
+//	string - The path of the node.
+func nodePath(node *Node) string {
+	var names []string
+	for current := node; current != nil && current.parent != nil; current = current.parent {
+		names = append([]string{strings.TrimSuffix(current.name, "/")}, names...)
+	}
+	return strings.Join(names, "/")
+}
+
+// filterTree removes the nodes that do not match the include and exclude patterns in the options.
+// A node is kept if it, or one of its ancestors, matches an include pattern, and neither it nor an
+// ancestor matches an exclude pattern. The ancestors of kept nodes are kept as well, so the tree stays
+// connected. If there are no include patterns, every node that is not excluded is kept.
+//
+// Parameters:
+//
+//	node - The root node of the tree to filter.
+//	opts - A pointer to an Options struct that specifies the include and exclude patterns.
+func filterTree(node *Node, opts *Options) {
+	if len(opts.includes) == 0 && len(opts.excludes) == 0 {
+		return
+	}
+	filterChildren(node, len(opts.includes) == 0, opts)
+}
+
+// filterChildren recursively filters the children of the given node.
+//
+// Parameters:
+//
+//	node - The node whose children to filter.
+//	included - Whether the node, or one of its ancestors, matched an include pattern.
+//	opts - A pointer to an Options struct that specifies the include and exclude patterns.
+//
+// Returns:
+//
+//	bool - True if any of the node's children were kept, false otherwise.
+func filterChildren(node *Node, included bool, opts *Options) bool {
+	var kept []*Node
+	for _, child := range node.children {
+		if matchesAny(opts.excludes, child) {
+			continue
+		}
+		childIncluded := included || matchesAny(opts.includes, child)
+		if filterChildren(child, childIncluded, opts) || childIncluded {
+			kept = append(kept, child)
+		}
+	}
+	// a directory that only loses some of its children is still a directory
+	if node.isDir() {
+		node.kind = KIND_DIR
+	}
+	node.children = append([]*Node{}, kept...)
+	return len(kept) > 0
+}
+
+// matchesAny reports whether any of the patterns match the given node.
+func matchesAny(patterns []*Pattern, node *Node) bool {
+	for _, pattern := range patterns {
+		if pattern.matches(node) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// addSeedInputs adds the inputs in test_files/src and a few odd inputs to the seed corpus of a fuzz target.
+func addSeedInputs(f *testing.F) {
+	files, _ := filepath.Glob(filepath.Join("test_files", "src", "*.txt"))
+	for _, file := range files {
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(contents))
+	}
+	for _, input := range []string{
+		"",
+		"\n\n",
+		"   \n  \n",
+		"a\n\t\tb\n  c\n",
+		"a\n        b\n  c\n d\n",
+		"a {x=1, y=2}\n  b {}\n  {c=3}\n  d {=}\n",
+		"src/\n  main.go {type=file}\n",
+		"a {type=file}\n  b\n",
+		"a\r\n  b\r\n\r\n",
+		"├── a\n│   └── b\n",
+	} {
+		f.Add(input)
+	}
+}
+
+// reverseParse parses the output of describeTree, with the root displayed, back into a tree.
+func reverseParse(t *testing.T, output string, opts *Options) *Node {
+	CHILD, LAST_CHILD, DIRECTORY, EMPTY := getPrefixes(opts)
+	lines := strings.Split(output, getLE())
+	root := &Node{name: lines[0], children: []*Node{}}
+	stack := []*Node{root}
+
+	for _, line := range lines[1:] {
+		depth := 0
+		for {
+			rest, ok := strings.CutPrefix(line, DIRECTORY)
+			if !ok {
+				rest, ok = strings.CutPrefix(line, EMPTY)
+			}
+			if !ok {
+				break
+			}
+			line = rest
+			depth++
+		}
+		name, ok := strings.CutPrefix(line, CHILD)
+		if !ok {
+			name, ok = strings.CutPrefix(line, LAST_CHILD)
+		}
+		if !ok {
+			t.Fatalf("reverseParse() line without a connector: %q", line)
+		}
+		if depth >= len(stack) {
+			t.Fatalf("reverseParse() line deeper than its parent: %q", line)
+		}
+		stack = stack[:depth+1]
+		parent := stack[depth]
+		name, attrs := parseAttributes(name)
+		node := &Node{name: name, children: []*Node{}, parent: parent, attrs: attrs}
+		parent.children = append(parent.children, node)
+		stack = append(stack, node)
+	}
+	return root
+}
+
+// equalTrees reports whether two trees have the same names and attributes, in the same structure.
+func equalTrees(a *Node, b *Node) bool {
+	if a.name != b.name || len(a.children) != len(b.children) || formatAttributes(a.attrs) != formatAttributes(b.attrs) {
+		return false
+	}
+	for i := range a.children {
+		if !equalTrees(a.children[i], b.children[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func FuzzParseInput(f *testing.F) {
+	addSeedInputs(f)
+
+	f.Fuzz(func(t *testing.T, input string) {
+		opts := DefaultOptions()
+		opts.attributes = true
+		root, err := parseInput(input, opts)
+		if err != nil {
+			// the input has a child of a node marked as a file, and stops at it
+			return
+		}
+
+		lines, blank := 0, 0
+		for _, line := range strings.Split(strings.ReplaceAll(input, "\r", ""), "\n") {
+			if line == "" {
+				continue
+			}
+			lines++
+			if strings.TrimLeft(line, " \t") == "" {
+				blank++
+			}
+		}
+		if count := countDescendants(root); count != lines-blank {
+			t.Fatalf("parseInput(%q) made %d nodes from %d lines with a name", input, count, lines-blank)
+		}
+		placeholderOpts := DefaultOptions()
+		placeholderOpts.emptyNames = "placeholder"
+		// a placeholder may be a child of a node marked as a file, which stops parsing
+		if placeholders, err := parseInput(input, placeholderOpts); err == nil && countDescendants(placeholders) != lines {
+			t.Fatalf("parseInput(%q) with placeholders made %d nodes from %d lines", input, countDescendants(placeholders), lines)
+		}
+
+		output := describeTree(root, opts)
+		if !utf8.ValidString(input) {
+			return
+		}
+		reversed := reverseParse(t, output, opts)
+		if !equalTrees(root, reversed) {
+			t.Fatalf("parseInput(%q) does not round-trip through describeTree:\n%s", input, output)
+		}
+	})
+}
+
+func FuzzParseDepth(f *testing.F) {
+	f.Add("    a", 2)
+	f.Add("\t\tb", 0)
+	f.Add("   ", 4)
+	f.Add("", -1)
+
+	f.Fuzz(func(t *testing.T, line string, indentSize int) {
+		depth := parseDepth(line, indentSize)
+		whitespace := len(line) - len(strings.TrimLeft(line, " \t"))
+
+		expected := whitespace
+		if indentSize > 0 {
+			expected = whitespace / indentSize
+		}
+		if depth != expected {
+			t.Fatalf("parseDepth(%q, %d) = %d, want %d", line, indentSize, depth, expected)
+		}
+		if indentSize > 0 && depth*indentSize > len(line) {
+			t.Fatalf("parseDepth(%q, %d) = %d, past the end of the line", line, indentSize, depth)
+		}
+	})
+}
+
+func FuzzTransformTree(f *testing.F) {
+	addSeedInputs(f)
+
+	variants := [][]string{
+		{"--collapse", "-s"},
+		{"-L", "1", "--summary-depths"},
+		{"-D", "-p", "-a"},
+		{"-X", "*b*", "-P", "attr:type"},
+		{"--sort", "natural", "--dirs-first", "--merge-duplicates"},
+		{"--sort", "type", "--ignore-case", "-D", "--collapse", "-L", "2", "-S"},
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, args := range variants {
+			opts := DefaultOptions()
+			if _, err := parseArgs(opts, args, optionFlags); err != nil {
+				t.Fatal(err)
+			}
+			root, err := parseInput(input, opts)
+			if err != nil {
+				// the input has a child of a node marked as a file, and stops at it
+				return
+			}
+			transformTree(root, opts)
+			var output strings.Builder
+			if err := writeOutput(&output, root, opts); err != nil {
+				t.Fatal(err)
+			}
+
+			if !canStream(opts) {
+				continue
+			}
+			var streamed strings.Builder
+			if err := streamTree(strings.NewReader(input), &streamed, opts); err != nil {
+				t.Fatal(err)
+			}
+			if streamed.String() != output.String() {
+				t.Fatalf("streamTree(%q, %v)\nactual = %q\nwant   = %q", input, args, streamed.String(), output.String())
+			}
+		}
+	})
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+//go:embed test_files/src/*.txt
+var EXAMPLES embed.FS
+
+// manExample is an example shown in the man page, rendered from one of the files in test_files/src.
+type manExample struct {
+	// description of the example
+	description string
+	// name of the input file, in test_files/src
+	file string
+	// options passed to treelike
+	args []string
+}
+
+// manExamples lists the examples shown in the man page.
+var manExamples = []manExample{
+	{"Display a tree read from a file:", "test_1.txt", nil},
+	{"Display directories with a trailing slash:", "test_4.txt", []string{"-s"}},
+	{"Display the full path of each node, with a custom root name:", "test_4.txt", []string{"-r", "~", "-p"}},
+	{"Display several top-level nodes without a root, using ASCII characters:", "test_5.txt", []string{"-D", "-c", "ascii"}},
+	{"Display a summary after the tree:", "test_2.txt", []string{"-S"}},
+}
+
+// manFlags returns the flags of the man command.
+func manFlags() []flagDef {
+	return commonFlags
+}
+
+// manHelpText generates and returns a strings.Builder containing the help text for the man command.
+//
+// Returns:
+//
+//	strings.Builder - A builder containing the formatted help text.
+func manHelpText() strings.Builder {
+	LE := getLE()
+	var builder strings.Builder
+	builder.WriteString("Usage: treelike man" + LE)
+	builder.WriteString("Prints the man page of treelike in roff format, e.g. `treelike man > treelike.1`." + LE)
+	builder.WriteString("" + LE)
+	builder.WriteString("Options:" + LE)
+	builder.WriteString(flagsHelpText(manFlags()))
+	return builder
+}
+
+// runMan runs the man command with the given arguments, and returns the exit code.
+//
+// Parameters:
+//
+//	args - The command-line arguments following the `man` command.
+//
+// Returns:
+//
+//	int - 0 on success, and 2 on errors.
+func runMan(args []string) int {
+	positional, err := parseArgs(DefaultOptions(), args, manFlags())
+	if err == nil && len(positional) > 0 {
+		err = fmt.Errorf("unexpected argument: %s", positional[0])
+	}
+	if err != nil {
+		handleArgsError(err, manHelpText())
+	}
+
+	page, err := manPage()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	fmt.Print(page)
+	return 0
+}
+
+// manPage generates the man page in roff format. The options and commands are taken from the same
+// definitions as the help text, and the examples are rendered from the files in test_files/src.
+//
+// Returns:
+//
+//	string - The man page.
+//	error - An error object if an example could not be rendered, otherwise nil.
+func manPage() (string, error) {
+	version, err := VERSION.ReadFile("version.txt")
+	if err != nil {
+		return "", fmt.Errorf("error getting version: %w", err)
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, ".TH TREELIKE 1 \"\" \"treelike %s\" \"User Commands\"\n", strings.TrimSpace(string(version)))
+	builder.WriteString(".SH NAME\n")
+	builder.WriteString("treelike \\- print a tree\\-like representation of the input\n")
+
+	builder.WriteString(".SH SYNOPSIS\n")
+	builder.WriteString(".B treelike\n")
+	builder.WriteString("[\\fIOPTIONS\\fR] [\\fITREE\\-STRUCTURE\\fR]\n")
+	for _, command := range commands {
+		builder.WriteString(".br\n")
+		builder.WriteString(".B treelike " + roffEscape(command.name) + "\n")
+		builder.WriteString("[\\fIOPTIONS\\fR]")
+		if command.args != "" {
+			builder.WriteString(" \\fI" + roffEscape(command.args) + "\\fR")
+		}
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString(".SH DESCRIPTION\n")
+	builder.WriteString(".B treelike\n")
+	builder.WriteString("prints a tree\\-like representation of the input, which is read from a file, standard input, or\n")
+	builder.WriteString("the command\\-line arguments. Each line of the input is a node, and its indentation sets its depth.\n")
+
+	builder.WriteString(".SH OPTIONS\n")
+	builder.WriteString(manFlagsText(mainFlags()))
+
+	builder.WriteString(".SH COMMANDS\n")
+	for _, command := range commands {
+		builder.WriteString(".TP\n\\fB" + roffEscape(command.name) + "\\fR")
+		if command.args != "" {
+			builder.WriteString(" \\fI" + roffEscape(command.args) + "\\fR")
+		}
+		builder.WriteString("\n")
+		builder.WriteString(roffText(command.usage) + ".\n")
+		var own []flagDef
+		for _, flag := range command.flags() {
+			if findFlag(mainFlags(), flag.long, false) == nil {
+				own = append(own, flag)
+			}
+		}
+		if len(own) > 0 {
+			builder.WriteString(".RS\n")
+			builder.WriteString(manFlagsText(own))
+			builder.WriteString(".RE\n")
+		}
+	}
+
+	builder.WriteString(".SH CONFIGURATION\n")
+	builder.WriteString("Default options are read from a\n")
+	builder.WriteString(".I " + roffEscape(CONFIG_FILE) + "\n")
+	builder.WriteString("file in the working directory, or from\n")
+	builder.WriteString(".IR " + roffEscape("$XDG_CONFIG_HOME/treelike/"+CONFIG_FILE) + " .\n")
+	builder.WriteString("Keys are the long option names, and the file can be written as TOML, YAML or JSON.\n")
+
+	builder.WriteString(".SH ENVIRONMENT\n")
+	builder.WriteString(".TP\n")
+	builder.WriteString(".B " + roffEscape(ENV_PREFIX) + "*\n")
+	builder.WriteString("Sets the option with the matching long name, e.g.\n")
+	builder.WriteString(".BR " + roffEscape(ENV_PREFIX+"CHARSET=ascii") + " .\n")
+	builder.WriteString("Repeated options are separated by commas. Environment variables override the configuration\n")
+	builder.WriteString("file, and command\\-line options override both.\n")
+
+	builder.WriteString(".SH EXAMPLES\n")
+	for _, example := range manExamples {
+		text, err := manExampleText(example)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(text)
+	}
+
+	builder.WriteString(".SH SEE ALSO\n")
+	builder.WriteString(".BR tree (1)\n")
+	return builder.String(), nil
+}
+
+// manFlagsText generates the roff paragraphs describing the given flags.
+//
+// Parameters:
+//
+//	flags - The flags to describe.
+//
+// Returns:
+//
+//	string - The roff paragraphs.
+func manFlagsText(flags []flagDef) string {
+	var builder strings.Builder
+	for _, flag := range flags {
+		var names []string
+		for _, name := range flagNames(flag) {
+			names = append(names, "\\fB"+roffEscape(name)+"\\fR")
+		}
+		if flag.short == "-" {
+			names = append([]string{"\\fB\\-\\fR"}, names...)
+		}
+		line := strings.Join(names, ", ")
+		if flag.value != "" {
+			line += " \\fI" + roffEscape(flag.value) + "\\fR"
+		}
+		builder.WriteString(".TP\n" + line + "\n")
+
+		usage := strings.ReplaceAll(flag.usage, "\n", ". ")
+		if len(flag.choices) > 0 {
+			usage += ". Valid values: " + strings.Join(flag.choices, ", ")
+		}
+		builder.WriteString(roffText(usage) + ".\n")
+	}
+	return builder.String()
+}
+
+// manExampleText generates the roff text of an example, showing the command and its output.
+//
+// Parameters:
+//
+//	example - The example to render.
+//
+// Returns:
+//
+//	string - The roff text.
+//	error - An error object if the example could not be rendered, otherwise nil.
+func manExampleText(example manExample) (string, error) {
+	source, err := EXAMPLES.ReadFile("test_files/src/" + example.file)
+	if err != nil {
+		return "", fmt.Errorf("error opening example %s: %w", example.file, err)
+	}
+	rendered, err := renderDocsBlock(string(source), example.args)
+	if err != nil {
+		return "", fmt.Errorf("error rendering example %s: %w", example.file, err)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(".PP\n" + roffText(example.description) + "\n")
+	builder.WriteString(".PP\n.RS\n.nf\n")
+	builder.WriteString("$ cat " + roffEscape(example.file) + "\n")
+	for _, line := range strings.Split(strings.TrimRight(strings.ReplaceAll(string(source), "\r", ""), "\n"), "\n") {
+		builder.WriteString(roffLine(line) + "\n")
+	}
+	command := slices.Concat([]string{"treelike"}, example.args, []string{"-f", example.file})
+	builder.WriteString("$ " + roffEscape(strings.Join(command, " ")) + "\n")
+	for _, line := range rendered {
+		builder.WriteString(roffLine(line) + "\n")
+	}
+	builder.WriteString(".fi\n.RE\n")
+	return builder.String(), nil
+}
+
+// roffText escapes a description for roff, and displays `quoted` words in bold.
+func roffText(text string) string {
+	parts := strings.Split(text, "`")
+	for i := range parts {
+		parts[i] = roffEscape(parts[i])
+		if i%2 == 0 {
+			continue
+		}
+		if i < len(parts)-1 {
+			parts[i] = "\\fB" + parts[i] + "\\fR"
+		} else {
+			parts[i] = "\\(ga" + parts[i]
+		}
+	}
+	return roffGuard(strings.Join(parts, ""))
+}
+
+// roffLine escapes a line of text for roff.
+func roffLine(line string) string {
+	return roffGuard(roffEscape(line))
+}
+
+// roffGuard prefixes an escaped line with a zero-width character if it starts with a period or an
+// apostrophe, so that it is not read as a request.
+func roffGuard(line string) string {
+	if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+		return "\\&" + line
+	}
+	return line
+}
+
+// roffEscape escapes backslashes, dashes and grave accents for roff.
+func roffEscape(text string) string {
+	return strings.NewReplacer("\\", "\\e", "-", "\\-", "`", "\\(ga").Replace(text)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestManPage(t *testing.T) {
+	page, err := manPage()
+	if err != nil {
+		t.Fatalf("manPage() error = %v", err)
+	}
+
+	for _, flag := range mainFlags() {
+		if !strings.Contains(page, "\\fB"+roffEscape("--"+flag.long)+"\\fR") {
+			t.Errorf("man page is missing --%s", flag.long)
+		}
+	}
+	for _, command := range commands {
+		if !strings.Contains(page, ".B treelike "+command.name+"\n") {
+			t.Errorf("man page is missing the %s command", command.name)
+		}
+	}
+	for _, example := range manExamples {
+		if !strings.Contains(page, "$ cat "+roffEscape(example.file)+"\n") {
+			t.Errorf("man page is missing the %s example", example.file)
+		}
+	}
+	requests := []string{"TH", "SH", "TP", "PP", "RS", "RE", "B", "I", "BR", "IR", "br", "nf", "fi"}
+	for _, line := range strings.Split(page, "\n") {
+		if !strings.HasPrefix(line, ".") {
+			continue
+		}
+		request, _, _ := strings.Cut(line[1:], " ")
+		if !slices.Contains(requests, request) {
+			t.Errorf("man page has an unescaped line: %q", line)
+		}
+	}
+}
+
+func TestRoffText(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Read from FILE", "Read from FILE"},
+		{"e.g. `src/main-java`", "e.g. \\fBsrc/main\\-java\\fR"},
+		{"a `b", "a \\(gab"},
+		{".hidden \\ path", "\\&.hidden \\e path"},
+		{"'quoted'", "\\&'quoted'"},
+	}
+
+	for _, test := range tests {
+		actual := roffText(test.input)
+		if actual != test.want {
+			t.Errorf("roffText(%q)\nactual = %q\nwant   = %q", test.input, actual, test.want)
+		}
+	}
+}
+
+func TestReadmeOptions(t *testing.T) {
+	readme, err := os.ReadFile("README.md")
+	if err != nil {
+		t.Fatalf("error opening README.md: %v", err)
+	}
+
+	for _, flag := range mainFlags() {
+		if !strings.Contains(string(readme), "--"+flag.long) {
+			t.Errorf("README.md is missing --%s", flag.long)
+		}
+	}
+	for _, command := range commands {
+		if !strings.Contains(string(readme), "- `"+strings.TrimSpace(command.name+" "+command.args)+"`") {
+			t.Errorf("README.md is missing the %s command", command.name)
+		}
+	}
+}
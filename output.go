@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// renderToFile renders the input specified in the options, and writes the output to the file given with
+// --out. The file is only written once the whole output has been rendered, so it is left as it was if
+// reading or rendering the input fails.
+//
+// Parameters:
+//
+//	opts - A pointer to an Options struct that specifies the input source, output file and formatting options.
+//
+// Returns:
+//
+//	bool - True if the file was written, false if it was unchanged and the skipUnchanged option is enabled.
+//	error - An error object if an error occurred, otherwise nil.
+//	int - An error code: 0 for success, 1 for reading and writing errors, 2 for missing input source.
+func renderToFile(opts *Options) (bool, error, int) {
+	var output bytes.Buffer
+	if err, code := renderInput(&output, opts); err != nil {
+		return false, err, code
+	}
+	written, err := writeFileAtomic(opts.outFile, output.Bytes(), opts.skipUnchanged)
+	if err != nil {
+		return false, fmt.Errorf("error writing file %s: %w", opts.outFile, err), 1
+	}
+	return written, nil, 0
+}
+
+// writeFileAtomic writes contents to a file by writing a temporary file in the same directory and renaming
+// it over the file, so the file is never left partly written. If the path is a symbolic link, the file it
+// links to is replaced and the link is kept. The permissions of an existing file are kept, and so are its
+// owner and group where the platform and the user's privileges allow it. A new file gets the default
+// permissions of the user's umask, like any file created with os.Create.
+//
+// Parameters:
+//
+//	path - The path of the file to write.
+//	contents - The contents to write.
+//	skipUnchanged - If true, the file is not written if it already has the same contents.
+//
+// Returns:
+//
+//	bool - True if the file was written, false if it was skipped.
+//	error - An error object if the file could not be written, otherwise nil.
+func writeFileAtomic(path string, contents []byte, skipUnchanged bool) (bool, error) {
+	path, err := resolveSymlinks(path)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(path)
+	if err == nil {
+		if skipUnchanged {
+			if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, contents) {
+				return false, nil
+			}
+		}
+	}
+
+	temp, err := createTempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(contents); err != nil {
+		temp.Close()
+		return false, err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return false, err
+	}
+	if err := temp.Close(); err != nil {
+		return false, err
+	}
+	if info != nil {
+		if err := os.Chmod(temp.Name(), info.Mode().Perm()); err != nil {
+			return false, err
+		}
+		// the owner can only be changed by privileged users, so the file is still replaced if it fails
+		chownLike(temp.Name(), info)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// createTempFile creates a new file with a random name in a directory, like os.CreateTemp. The file is
+// created with the permissions os.Create uses, 0666 before the umask, rather than 0600, so it can be
+// renamed into place as a new file.
+//
+// Parameters:
+//
+//	dir - The directory to create the file in.
+//	prefix - The start of the file name, which is followed by a random number and `.tmp`.
+//
+// Returns:
+//
+//	*os.File - The file, opened for writing.
+//	error - An error object if the file could not be created, otherwise nil.
+func createTempFile(dir, prefix string) (*os.File, error) {
+	for range 10000 {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10)+".tmp")
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if !errors.Is(err, os.ErrExist) {
+			return file, err
+		}
+	}
+	return nil, errors.New("could not create a temporary file in " + dir)
+}
+
+// resolveSymlinks returns the path of the file that a path refers to, following symbolic links. A link to
+// a file that does not exist yet resolves to that file, and a path that does not exist is returned as-is.
+//
+// Parameters:
+//
+//	path - The path to resolve.
+//
+// Returns:
+//
+//	string - The path of the file the path refers to.
+//	error - An error object if a link could not be read, otherwise nil.
+func resolveSymlinks(path string) (string, error) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved, nil
+	}
+	for i := 0; i < 40; i++ {
+		info, err := os.Lstat(path)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return "", errors.New("too many levels of symbolic links")
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// chownLike does nothing, as files have no Unix owner and group on this platform.
+func chownLike(path string, info os.FileInfo) error {
+	return nil
+}
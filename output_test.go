@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFile reads a file, and fails the test if it can not be read.
+func readFile(t *testing.T, path string) string {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(contents)
+}
+
+func TestRenderToFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "tree.txt")
+	out := filepath.Join(dir, "out.txt")
+	if err := os.WriteFile(input, []byte("a\n  b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(out, []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.fromFiles = []string{input}
+	opts.outFile = out
+	written, err, _ := renderToFile(opts)
+	if err != nil || !written {
+		t.Fatalf("renderToFile() = %v, %v", written, err)
+	}
+	expected := ".\n└── a\n    └── b\n"
+	if actual := readFile(t, out); actual != expected {
+		t.Errorf("renderToFile() contents\nactual = %q\nwant   = %q", actual, expected)
+	}
+	if info, err := os.Stat(out); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("renderToFile() did not keep the file mode: %v, %v", info.Mode(), err)
+	}
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(out, past, past)
+	opts.skipUnchanged = true
+	written, err, _ = renderToFile(opts)
+	if err != nil || written {
+		t.Errorf("renderToFile() with skipUnchanged = %v, %v", written, err)
+	}
+	if info, err := os.Stat(out); err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("renderToFile() with skipUnchanged replaced the file")
+	}
+
+	opts.fromFiles = []string{filepath.Join(dir, "missing.txt")}
+	if _, err, code := renderToFile(opts); err == nil || code != 1 {
+		t.Errorf("renderToFile() with a missing input = %v, %d", err, code)
+	}
+	if actual := readFile(t, out); actual != expected {
+		t.Errorf("renderToFile() with a missing input changed the file\nactual = %q\nwant   = %q", actual, expected)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("renderToFile() left %d files in the directory, want 2", len(files))
+	}
+}
+
+func TestRenderToFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "tree.txt")
+	if err := os.WriteFile(input, []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.fromFiles = []string{input}
+	opts.outFile = filepath.Join(dir, "missing", "out.txt")
+	_, err, code := renderToFile(opts)
+	expected := "error writing file " + opts.outFile + ": "
+	if err == nil || !strings.HasPrefix(err.Error(), expected) || code != 1 {
+		t.Errorf("renderToFile() error\nactual = %v\nwant   = %q", err, expected)
+	}
+}
+
+func TestWatchInputToFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "tree.txt")
+	out := filepath.Join(dir, "out.txt")
+	if err := os.WriteFile(input, []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.fromFiles = []string{input}
+	opts.outFile = out
+	output := &syncBuilder{}
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- watchInput(output, opts, stop)
+	}()
+
+	waitFor(t, output, "updated: "+out+"\n")
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("watchInput() error = %v", err)
+	}
+	if strings.Contains(output.String(), ANSI_CLEAR) {
+		t.Errorf("watchInput() with an output file cleared the terminal")
+	}
+	expected := ".\n└── a\n"
+	if actual := readFile(t, out); actual != expected {
+		t.Errorf("watchInput() contents\nactual = %q\nwant   = %q", actual, expected)
+	}
+}
+
+func TestWriteFileAtomicSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dir, "docs", "tree.txt")
+	if err := os.WriteFile(target, []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "tree.txt")
+	if err := os.Symlink(filepath.Join("docs", "tree.txt"), link); err != nil {
+		t.Fatal(err)
+	}
+	dangling := filepath.Join(dir, "new.txt")
+	if err := os.Symlink(filepath.Join("docs", "new.txt"), dangling); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{link, dangling} {
+		if _, err := writeFileAtomic(path, []byte("new\n"), false); err != nil {
+			t.Fatalf("writeFileAtomic(%s) error = %v", path, err)
+		}
+		if info, err := os.Lstat(path); err != nil || info.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("writeFileAtomic(%s) replaced the symbolic link", path)
+		}
+		if actual := readFile(t, path); actual != "new\n" {
+			t.Errorf("writeFileAtomic(%s) contents\nactual = %q\nwant   = %q", path, actual, "new\n")
+		}
+	}
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("writeFileAtomic() did not keep the mode of the link target")
+	}
+
+	loop := filepath.Join(dir, "loop.txt")
+	if err := os.Symlink("loop.txt", loop); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeFileAtomic(loop, []byte("new\n"), false); err == nil {
+		t.Errorf("writeFileAtomic() with a symbolic link loop, expected an error")
+	}
+}
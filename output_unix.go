@@ -0,0 +1,26 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// chownLike gives a file the owner and group of another file.
+//
+// Parameters:
+//
+//	path - The path of the file to change.
+//	info - The info of the file whose owner and group to copy.
+//
+// Returns:
+//
+//	error - An error object if the owner could not be changed, otherwise nil.
+func chownLike(path string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return os.Chown(path, int(stat.Uid), int(stat.Gid))
+}
@@ -0,0 +1,50 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestWriteFileAtomicOwner(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing the owner of a file requires root")
+	}
+	path := filepath.Join(t.TempDir(), "out.txt")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chown(path, 1234, 5678); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeFileAtomic(path, []byte("new\n"), false); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && (stat.Uid != 1234 || stat.Gid != 5678) {
+		t.Errorf("writeFileAtomic() owner = %d:%d, want 1234:5678", stat.Uid, stat.Gid)
+	}
+}
+
+func TestWriteFileAtomicUmask(t *testing.T) {
+	old := syscall.Umask(0o027)
+	defer syscall.Umask(old)
+
+	path := filepath.Join(t.TempDir(), "out.txt")
+	if _, err := writeFileAtomic(path, []byte("new\n"), false); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("writeFileAtomic() mode of a new file = %v, want %v", info.Mode().Perm(), os.FileMode(0o640))
+	}
+}
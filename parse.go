@@ -2,8 +2,11 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -36,6 +39,7 @@ func parseDepth(line string, indentSize int) int {
 // parseInput parses a string input representing a tree structure and returns the root node of the tree.
 // The input string should use indentation to represent the depth of each node in the tree.
 // The function handles different line endings and adjusts the indentation size based on the input.
+// Parsing stops at an invalid line, such as a child of a node marked as a file.
 //
 // Parameters:
 //
@@ -43,48 +47,315 @@ func parseDepth(line string, indentSize int) int {
 //
 // Returns:
 //
-//	*Node - The root node of the parsed tree structure.
-func parseInput(input string, opts *Options) *Node {
-	input = strings.Replace(input, "\r", "", -1)
+//	*Node - The root node of the parsed tree structure, parsed up to the invalid line if there is one.
+//	error - A *parseError if a line is invalid, otherwise nil.
+func parseInput(input string, opts *Options) (*Node, error) {
+	return parseReader(strings.NewReader(input), opts)
+}
+
+// readError is returned by parseReader when reading the input fails, and reports how far it got.
+type readError struct {
+	// number of bytes of the complete lines read before the failure; a partial line read after them is
+	// not parsed, so this is where it starts
+	offset int64
+	err    error
+}
+
+func (e *readError) Error() string {
+	return fmt.Sprintf("at byte %d: %v", e.offset, e.err)
+}
+
+func (e *readError) Unwrap() error {
+	return e.err
+}
+
+// parseError is returned by parseReader when a line of the input is invalid.
+type parseError struct {
+	// number of the invalid line, starting from 1
+	line int
+	err  error
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+func (e *parseError) Unwrap() error {
+	return e.err
+}
+
+// emptyNameModes lists the valid values for the emptyNames option.
+var emptyNameModes = []string{"skip", "placeholder", "error"}
+
+// parseReader parses a tree structure read from r, like parseInput. The input is read and parsed one line
+// at a time, so it is never held in memory as a whole, and lines may be of any length.
+//
+// Parameters:
+//
+//	r - The reader to read the tree structure from.
+//	opts - A pointer to an Options struct that specifies the root name.
+//
+// Returns:
+//
+//	*Node - The root node of the tree parsed so far.
+//	error - A *readError with the byte offset of the failure if reading failed, a *parseError if a line is
+//	        invalid, otherwise nil.
+func parseReader(r io.Reader, opts *Options) (*Node, error) {
+	return parseEvents(r, opts, func(event parseEvent) error {
+		if event.kind == EVENT_ENTER {
+			event.node.parent.children = append(event.node.parent.children, event.node)
+		}
+		return nil
+	})
+}
+
+// parseEvent is emitted by parseEvents for each node it reads.
+type parseEvent struct {
+	kind parseEventKind
+	// node the event is about; its parent is set, but it is not added to the parent's children
+	node *Node
+	// for EVENT_LEAVE, whether the node is the last child of its parent
+	last bool
+}
+
+// parseEvents parses a tree structure read from r one line at a time, and calls the handler for each node
+// instead of building the tree. An EVENT_ENTER event is emitted when a node is read, and an EVENT_LEAVE
+// event once all of its descendants have been read, which is when the next line at the same or a lower
+// depth is read, or the input ends. If reading fails, the partial line read before the failure is not
+// parsed, as its name may be cut short. Only the ancestors of the current node are kept by the parser, so
+// the handler decides how much of the tree is held in memory: adding each node to its parent's children
+// on EVENT_ENTER builds the whole tree, like parseReader does.
+//
+// Parameters:
+//
+//	r - The reader to read the tree structure from.
+//	opts - A pointer to an Options struct that specifies the root name.
+//	handler - The function called for each event. Parsing stops if it returns an error.
+//
+// Returns:
+//
+//	*Node - The root node, which is the parent of the top-level nodes. It emits no events.
+//	error - A *readError with the byte offset of the failure if reading failed, a *parseError if a line is
+//	        invalid, the error returned by the handler, or nil.
+func parseEvents(r io.Reader, opts *Options, handler func(event parseEvent) error) (*Node, error) {
+	parser := newTreeParser(opts, handler)
+	reader := bufio.NewReader(r)
+	var offset int64
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return parser.root, &readError{offset: offset, err: err}
+		}
+		offset += int64(len(line))
+		if line != "" {
+			if err := parser.addLine(strings.TrimSuffix(strings.ReplaceAll(line, "\r", ""), LE_UNIX)); err != nil {
+				return parser.root, err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	return parser.root, parser.finish()
+}
+
+// treeParser reads the lines of a tree structure one at a time, and emits an event for each node.
+type treeParser struct {
+	// root of the tree
+	root *Node
+	// node added by the previous line and its ancestors, from the root down
+	stack []*Node
+	// number of whitespace characters per level, detected from the first indented line
+	indentSize int
+	// number of lines read
+	lines int
+	// how to handle lines with only whitespace, one of emptyNameModes
+	emptyNames string
+	// function called for each event
+	handler func(event parseEvent) error
+}
+
+// newTreeParser creates a treeParser with an empty tree, whose root is named after the rootPath option.
+//
+// Parameters:
+//
+//	opts - A pointer to an Options struct that specifies the root name.
+//	handler - The function called for each event.
+//
+// Returns:
+//
+//	*treeParser - The parser.
+func newTreeParser(opts *Options, handler func(event parseEvent) error) *treeParser {
 	rootName := "."
 	if opts.rootPath != "" && opts.rootPath != "." {
 		rootName = opts.rootPath
 	}
-	root := &Node{rootName, 0, []*Node{}, nil}
-	current := root
-	indentSize := 0
-	LE := LE_UNIX
+	root := &Node{name: rootName, depth: 0, children: []*Node{}, parent: nil}
+	return &treeParser{root: root, stack: []*Node{root}, emptyNames: opts.emptyNames, handler: handler}
+}
 
-	for _, line := range strings.Split(input, LE) {
-		if line == "" {
-			continue
-		}
-		depth := parseDepth(line, indentSize)
-		if depth > 0 && indentSize == 0 {
-			indentSize = depth
-			depth /= indentSize
-		}
-		if depth < 0 {
-			depth = 0
-		}
-		name := line[depth*indentSize:]
-		if depth <= current.depth && current.parent != nil {
-			for current != nil && current.depth >= depth {
-				current = current.parent
-			}
+// addLine reads the node described by a line, which is a child of the closest previous node with a smaller
+// depth. The nodes that are not ancestors of the new node are left first. Empty lines are ignored, and
+// trailing whitespace is removed from names. Lines with only whitespace are handled according to the
+// emptyNames option: skipped like empty lines, read as a node named EMPTY_NAME_PLACEHOLDER, or reported
+// as an error. Their indentation is not used to detect the indent size; before it is known, any
+// indentation counts as one level.
+//
+// Parameters:
+//
+//	line - The line, without its line ending.
+//
+// Returns:
+//
+//	error - A *parseError if the line is invalid, the error returned by the handler, or nil.
+func (p *treeParser) addLine(line string) error {
+	p.lines++
+	if line == "" {
+		return nil
+	}
+	blank := strings.TrimLeft(line, " \t") == ""
+	if blank && p.emptyNames == "error" {
+		return &parseError{line: p.lines, err: errors.New("empty node name")}
+	}
+	if blank && p.emptyNames != "placeholder" {
+		return nil
+	}
+
+	depth := parseDepth(line, p.indentSize)
+	if blank && p.indentSize == 0 {
+		depth = min(depth, 1)
+	} else if depth > 0 && p.indentSize == 0 {
+		p.indentSize = depth
+		depth /= p.indentSize
+	}
+	if depth < 0 {
+		depth = 0
+	}
+	name := strings.TrimRight(line[depth*p.indentSize:], " \t")
+	if blank {
+		name = EMPTY_NAME_PLACEHOLDER
+	}
+
+	top := len(p.stack) - 1
+	for top > 0 && p.stack[top].depth >= depth {
+		top--
+	}
+	parent := p.stack[top]
+	if err := p.leave(top+1, parent); err != nil {
+		return err
+	}
+	if parent.kind == KIND_FILE {
+		return &parseError{line: p.lines, err: fmt.Errorf("%s is marked as a file and can not have children", parent.name)}
+	}
+
+	name, attrs := parseAttributes(name)
+	node := &Node{name: name, depth: depth, children: []*Node{}, parent: parent, attrs: attrs, kind: parseKind(name, attrs)}
+	p.stack = append(p.stack, node)
+	return p.handler(parseEvent{kind: EVENT_ENTER, node: node})
+}
+
+// finish leaves all the nodes that are still open, once the input has ended.
+//
+// Returns:
+//
+//	error - The error returned by the handler, or nil.
+func (p *treeParser) finish() error {
+	return p.leave(1, nil)
+}
+
+// leave emits an EVENT_LEAVE event for each node above the given size of the stack, from the deepest up,
+// and removes them from the stack.
+//
+// Parameters:
+//
+//	size - The size of the stack once the nodes are left.
+//	parent - The parent of the next node, or nil if the input ended. The node left among its children is
+//	         not the last one.
+//
+// Returns:
+//
+//	error - The error returned by the handler, or nil.
+func (p *treeParser) leave(size int, parent *Node) error {
+	for len(p.stack) > size {
+		node := p.stack[len(p.stack)-1]
+		p.stack = p.stack[:len(p.stack)-1]
+		if err := p.handler(parseEvent{kind: EVENT_LEAVE, node: node, last: node.parent != parent}); err != nil {
+			return err
 		}
-		if current == nil {
-			current = root
+	}
+	return nil
+}
+
+// parseAttributes splits a trailing attribute block off a node name. An attribute block is a
+// comma-separated list of key=value pairs wrapped in braces at the end of the line, such as
+// `main.go {type=file, size=2KB, owner=@web}`. If the name does not end with a well-formed block,
+// it is returned unchanged along with a nil map.
+//
+// Parameters:
+//
+//	name - The raw node name, as it appears in the input.
+//
+// Returns:
+//
+//	string - The node name without the attribute block.
+//	map[string]string - The parsed attributes, or nil if there are none.
+func parseAttributes(name string) (string, map[string]string) {
+	trimmed := strings.TrimRight(name, " \t")
+	if !strings.HasSuffix(trimmed, "}") {
+		return name, nil
+	}
+	start := strings.LastIndex(trimmed, "{")
+	if start < 0 {
+		return name, nil
+	}
+	body := strings.TrimSpace(trimmed[start+1 : len(trimmed)-1])
+	if body == "" {
+		return name, nil
+	}
+	attrs := map[string]string{}
+	for _, pair := range strings.Split(body, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return name, nil
 		}
-		current.children = append(current.children, &Node{name, depth, []*Node{}, current})
-		current = current.children[len(current.children)-1]
+		attrs[key] = strings.TrimSpace(value)
+	}
+	stripped := strings.TrimRight(trimmed[:start], " \t")
+	if stripped == "" {
+		return name, nil
+	}
+	return stripped, attrs
+}
+
+// parseKind determines the kind of a node from its name and attributes. A `type` attribute of
+// "dir", "directory" or "file" sets the kind explicitly; otherwise a name ending with `/` marks the
+// node as a directory. Any other node's kind is left to be inferred from its children.
+//
+// Parameters:
+//
+//	name - The node name, without its attribute block.
+//	attrs - The node attributes.
+//
+// Returns:
+//
+//	NodeKind - The kind of the node.
+func parseKind(name string, attrs map[string]string) NodeKind {
+	switch strings.ToLower(attrs["type"]) {
+	case "dir", "directory":
+		return KIND_DIR
+	case "file":
+		return KIND_FILE
+	}
+	if strings.HasSuffix(name, "/") {
+		return KIND_DIR
 	}
-	return root
+	return KIND_AUTO
 }
 
-// parseRawInput reads input based on the provided options and returns it as a strings.Builder.
-// It can read from stdin, a file, or an extra string provided in the options.
-// If an error occurs during reading, it returns the error with a description and an error code.
+// readInput reads the input specified in the options and parses it into a tree. The input is read from
+// stdin, from one or more files, combined with parseInputFiles, or from the positional arguments.
 //
 // Parameters:
 //
@@ -92,39 +363,112 @@ func parseInput(input string, opts *Options) *Node {
 //
 // Returns:
 //
-//	strings.Builder - The input read from the specified source.
+//	*Node - The root node of the parsed tree.
 //	error - An error object if an error occurred, otherwise nil.
 //	int - An error code: 0 for success, 1 for reading errors, 2 for missing input source.
-func parseRawInput(opts *Options) (strings.Builder, error, int) {
-	var input strings.Builder
-
+func readInput(opts *Options) (*Node, error, int) {
+	var node *Node
+	var err error
 	if opts.fromStdin {
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			input.WriteString(scanner.Text() + "\n")
-		}
-		if err := scanner.Err(); err != nil {
-			return input, fmt.Errorf("error reading from stdin: %w", err), 1
+		node, err = readTreeSource("-", opts)
+	} else if len(opts.fromFiles) > 1 {
+		node, err = parseInputFiles(opts.fromFiles, opts)
+	} else if len(opts.fromFiles) == 1 {
+		node, err = readTreeSource(opts.fromFiles[0], opts)
+	} else if opts.extra.Len() > 0 {
+		node, err = parseReader(strings.NewReader(opts.extra.String()), opts)
+	} else {
+		help := helpText()
+		return nil, fmt.Errorf(help.String()), 2
+	}
+	if err != nil {
+		return nil, err, 1
+	}
+	return node, nil, 0
+}
+
+// readTreeSource reads and parses a tree from a file, or from stdin if the name is `-`.
+//
+// Parameters:
+//
+//	name - The file name, or `-` for stdin.
+//	opts - A pointer to an Options struct that specifies parsing options.
+//
+// Returns:
+//
+//	*Node - The root node of the parsed tree.
+//	error - An error object if the source could not be read, otherwise nil.
+func readTreeSource(name string, opts *Options) (*Node, error) {
+	if name == "-" {
+		root, err := parseReader(os.Stdin, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error reading from stdin: %w", err)
 		}
-	} else if opts.fromFile != "" {
-		file, err := os.Open(opts.fromFile)
+		return root, nil
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %s: %w", name, err)
+	}
+	defer file.Close()
+	root, err := parseReader(file, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s: %w", name, err)
+	}
+	return root, nil
+}
+
+// parseInputFiles reads and parses several tree files, and combines them into one tree under a shared
+// root. Each file is added as a subtree named after the file, without its directory, so the output does
+// not depend on the working directory. If the mergeFiles option is enabled, the top-level nodes of all
+// files are added to the root instead, and nodes with the same name are merged.
+//
+// Parameters:
+//
+//	files - The paths of the files to read; `-` reads from stdin.
+//	opts - A pointer to an Options struct that specifies the root name and how to combine the files.
+//
+// Returns:
+//
+//	*Node - The root node of the combined tree.
+//	error - An error object if a file could not be read, otherwise nil.
+func parseInputFiles(files []string, opts *Options) (*Node, error) {
+	root := newTreeParser(opts, nil).root
+	for _, file := range files {
+		tree, err := readTreeSource(file, opts)
 		if err != nil {
-			return input, fmt.Errorf("error opening file %s: %w", opts.fromFile, err), 1
+			return nil, err
 		}
-		defer file.Close()
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			input.WriteString(scanner.Text() + "\n")
+		if opts.mergeFiles {
+			for _, child := range tree.children {
+				child.parent = root
+				root.children = append(root.children, child)
+			}
+			continue
 		}
-		if err := scanner.Err(); err != nil {
-			return input, fmt.Errorf("error reading from file: %w", err), 1
+		node := &Node{name: filepath.Base(file), depth: 0, children: tree.children, parent: root, kind: KIND_DIR}
+		for _, child := range node.children {
+			child.parent = node
+			shiftDepth(child, 1)
 		}
-	} else if opts.extra.Len() > 0 {
-		input.WriteString(opts.extra.String())
-	} else {
-		help := helpText()
-		return input, fmt.Errorf(help.String()), 2
+		root.children = append(root.children, node)
 	}
+	if opts.mergeFiles {
+		mergeDuplicates(root)
+	}
+	return root, nil
+}
 
-	return input, nil, 0
+// shiftDepth adds the given offset to the depth of a node and all of its descendants.
+//
+// Parameters:
+//
+//	node - The node to shift.
+//	offset - The number of levels to add.
+func shiftDepth(node *Node, offset int) {
+	node.depth += offset
+	for _, child := range node.children {
+		shiftDepth(child, offset)
+	}
 }
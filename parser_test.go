@@ -1,6 +1,24 @@
 package main
 
-import "testing"
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// mustParseInput parses a tree structure, and fails the test if it is invalid.
+func mustParseInput(t testing.TB, input string, opts *Options) *Node {
+	t.Helper()
+	root, err := parseInput(input, opts)
+	if err != nil {
+		t.Fatalf("parseInput(%q) error = %v", input, err)
+	}
+	return root
+}
 
 func TestParseDepth(t *testing.T) {
 	tests := []struct {
@@ -25,7 +43,7 @@ func TestParseDepth(t *testing.T) {
 func TestParseInput(t *testing.T) {
 	input := "root\n    child1\n    child2\n        grandchild1\n"
 	opts := DefaultOptions()
-	root := parseInput(input, opts)
+	root := mustParseInput(t, input, opts)
 
 	if root.name != "." {
 		t.Errorf("Expected root name to be '.', got %s", root.name)
@@ -95,3 +113,256 @@ func TestIsLastChild(t *testing.T) {
 		t.Errorf("Expected child2 to be the last child")
 	}
 }
+
+func TestParseAttributes(t *testing.T) {
+	tests := []struct {
+		line         string
+		expectedName string
+		expected     map[string]string
+	}{
+		{"main.go {type=file, size=2KB, owner=@web}", "main.go", map[string]string{"type": "file", "size": "2KB", "owner": "@web"}},
+		{"main.go{generated=true}", "main.go", map[string]string{"generated": "true"}},
+		{"main.go {deprecated=}", "main.go", map[string]string{"deprecated": ""}},
+		{"main.go", "main.go", nil},
+		{"main.go {}", "main.go {}", nil},
+		{"func() {return}", "func() {return}", nil},
+		{"{a=b}", "{a=b}", nil},
+	}
+
+	for _, test := range tests {
+		name, attrs := parseAttributes(test.line)
+		if name != test.expectedName {
+			t.Errorf("parseAttributes(%q) name\n actual = %q\nwant   = %q", test.line, name, test.expectedName)
+		}
+		if len(attrs) != len(test.expected) {
+			t.Errorf("parseAttributes(%q) attrs\n actual = %v\nwant   = %v", test.line, attrs, test.expected)
+			continue
+		}
+		for key, value := range test.expected {
+			if attrs[key] != value {
+				t.Errorf("parseAttributes(%q) attrs[%q]\n actual = %q\nwant   = %q", test.line, key, attrs[key], value)
+			}
+		}
+	}
+}
+
+func TestGetNameAttributes(t *testing.T) {
+	root := mustParseInput(t, "src\n  main.go {owner=@web, size=2KB}\n", DefaultOptions())
+	node := root.children[0].children[0]
+
+	if owner, ok := node.attr("owner"); !ok || owner != "@web" {
+		t.Errorf("Expected owner attribute to be '@web', got %q", owner)
+	}
+
+	opts := DefaultOptions()
+	result := getName(node, opts)
+	expected := "main.go"
+	if result != expected {
+		t.Errorf("getName()\n actual = %q\nwant   = %q", result, expected)
+	}
+
+	opts.attributes = true
+	opts.fullPath = true
+	result = getName(node, opts)
+	expected = "./src/main.go {owner=@web, size=2KB}"
+	if result != expected {
+		t.Errorf("getName()\n actual = %q\nwant   = %q", result, expected)
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	root := mustParseInput(t, "src/\n  cache {type=dir}\n  empty/\n  main.go\n  gen {type=file}\n", DefaultOptions())
+	src := root.children[0]
+
+	tests := []struct {
+		node     *Node
+		expected bool
+	}{
+		{src, true},
+		{src.children[0], true},
+		{src.children[1], true},
+		{src.children[2], false},
+		{src.children[3], false},
+	}
+
+	for _, test := range tests {
+		if test.node.isDir() != test.expected {
+			t.Errorf("isDir(%q)\n actual = %v\nwant   = %v", test.node.name, test.node.isDir(), test.expected)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.trailingSlash = true
+	result := describeTree(root, opts)
+	expected := ".\n└── src/\n    ├── cache/\n    ├── empty/\n    ├── main.go\n    └── gen"
+	if result != expected {
+		t.Errorf("describeTree()\n actual = %q\nwant   = %q", result, expected)
+	}
+
+	file := &Node{name: "gen", children: []*Node{{name: "out"}}, kind: KIND_FILE}
+	if file.isDir() {
+		t.Errorf("isDir(%q) with children = true, want false", file.name)
+	}
+
+	root, err := parseInput("src\n  gen {type=file}\n    out\n", DefaultOptions())
+	expected = "line 3: gen is marked as a file and can not have children"
+	if err == nil || err.Error() != expected {
+		t.Errorf("parseInput() error\n actual = %v\nwant   = %q", err, expected)
+	}
+	if actual := countDescendants(root); actual != 2 {
+		t.Errorf("parseInput() with an error read %d nodes, want 2", actual)
+	}
+}
+
+func TestParseInputFiles(t *testing.T) {
+	dir := t.TempDir()
+	api := filepath.Join(dir, "api.txt")
+	web := filepath.Join(dir, "web.txt")
+	if err := os.WriteFile(api, []byte("src\n  api\n    main.go\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(web, []byte("src\n  web\n    index.ts\nREADME.md\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		merge    bool
+		expected string
+	}{
+		{false, ".\n├── api.txt\n│   └── src\n│       └── api\n│           └── main.go\n└── web.txt\n    ├── src\n    │   └── web\n    │       └── index.ts\n    └── README.md"},
+		{true, ".\n├── src\n│   ├── api\n│   │   └── main.go\n│   └── web\n│       └── index.ts\n└── README.md"},
+	}
+
+	for _, test := range tests {
+		opts := DefaultOptions()
+		opts.mergeFiles = test.merge
+		root, err := parseInputFiles([]string{api, web}, opts)
+		if err != nil {
+			t.Fatalf("parseInputFiles() error = %v", err)
+		}
+		result := describeTree(root, opts)
+		if result != test.expected {
+			t.Errorf("parseInputFiles(merge=%v)\n actual = %q\nwant   = %q", test.merge, result, test.expected)
+		}
+	}
+
+	if _, err := parseInputFiles([]string{api, filepath.Join(dir, "missing.txt")}, DefaultOptions()); err == nil {
+		t.Errorf("parseInputFiles() with a missing file, expected an error")
+	}
+}
+
+func TestParseReaderLongLines(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	root, err := parseReader(strings.NewReader("a\n  "+long+"\r\n  b"), DefaultOptions())
+	if err != nil {
+		t.Fatalf("parseReader() error = %v", err)
+	}
+
+	a := root.children[0]
+	if len(a.children) != 2 || a.children[0].name != long || a.children[1].name != "b" {
+		t.Errorf("parseReader() did not parse the long line, got %d children", len(a.children))
+	}
+}
+
+func TestParseReaderError(t *testing.T) {
+	failure := errors.New("connection reset")
+	reader := io.MultiReader(strings.NewReader("a\n  b\n  c"), iotest.ErrReader(failure))
+
+	root, err := parseReader(reader, DefaultOptions())
+	expected := "at byte 6: connection reset"
+	if err == nil || err.Error() != expected {
+		t.Errorf("parseReader() error\n actual = %v\nwant   = %q", err, expected)
+	}
+	if !errors.Is(err, failure) {
+		t.Errorf("parseReader() error does not wrap the read error")
+	}
+	if len(root.children) != 1 || len(root.children[0].children) != 1 || root.children[0].children[0].name != "b" {
+		t.Errorf("parseReader() did not return only the complete lines read before the error")
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	var events []string
+	root, err := parseEvents(strings.NewReader("a\n  b\n    c\n  d\ne\n"), DefaultOptions(), func(event parseEvent) error {
+		switch {
+		case event.kind == EVENT_ENTER:
+			events = append(events, "enter "+event.node.name+" under "+event.node.parent.name)
+		case event.last:
+			events = append(events, "leave "+event.node.name+" (last)")
+		default:
+			events = append(events, "leave "+event.node.name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parseEvents() error = %v", err)
+	}
+
+	expected := []string{
+		"enter a under .",
+		"enter b under a",
+		"enter c under b",
+		"leave c (last)",
+		"leave b",
+		"enter d under a",
+		"leave d (last)",
+		"leave a",
+		"enter e under .",
+		"leave e (last)",
+	}
+	if strings.Join(events, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("parseEvents()\n actual = %q\nwant   = %q", events, expected)
+	}
+	if len(root.children) != 0 {
+		t.Errorf("parseEvents() added %d nodes to the root", len(root.children))
+	}
+
+	failure := errors.New("stop")
+	_, err = parseEvents(strings.NewReader("a\nb\n"), DefaultOptions(), func(event parseEvent) error {
+		return failure
+	})
+	if err != failure {
+		t.Errorf("parseEvents() error\n actual = %v\nwant   = %v", err, failure)
+	}
+}
+
+func TestEmptyNames(t *testing.T) {
+	input := "a  \n  \t\n  b\t\n    \n  /\n    c\n"
+
+	tests := []struct {
+		mode     string
+		args     []string
+		expected string
+	}{
+		{"skip", nil, ".\n└── a\n    ├── b\n    └── /\n        └── c"},
+		{"placeholder", nil, ".\n└── a\n    ├── (empty)\n    ├── b\n    │   └── (empty)\n    └── /\n        └── c"},
+		{"skip", []string{"-s"}, ".\n└── a/\n    ├── b\n    └── /\n        └── c"},
+		{"skip", []string{"-D", "-p"}, "./a\n├── ./a/b\n└── ./a/\n    └── ./a/c"},
+		{"placeholder", []string{"-s", "--collapse", "--merge-duplicates", "--sort", "name"}, ".\n└── a/\n    ├── (empty)\n    ├── /\n    │   └── c\n    └── b/\n        └── (empty)"},
+	}
+
+	for _, test := range tests {
+		opts := DefaultOptions()
+		opts.emptyNames = test.mode
+		if _, err := parseArgs(opts, test.args, optionFlags); err != nil {
+			t.Fatal(err)
+		}
+		root, err := parseReader(strings.NewReader(input), opts)
+		if err != nil {
+			t.Fatalf("parseReader(%s) error = %v", test.mode, err)
+		}
+		transformTree(root, opts)
+		result := describeTree(root, opts)
+		if result != test.expected {
+			t.Errorf("describeTree(%s, %v)\n actual = %q\nwant   = %q", test.mode, test.args, result, test.expected)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.emptyNames = "error"
+	_, err := parseReader(strings.NewReader(input), opts)
+	expected := "line 2: empty node name"
+	if err == nil || err.Error() != expected {
+		t.Errorf("parseReader(error) error\n actual = %v\nwant   = %q", err, expected)
+	}
+}
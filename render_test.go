@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// legacyDescribeTree is the original implementation of describeTree, which builds the output of each
+// subtree and walks the ancestors of every node. It is kept to check that renderTree produces the same
+// output, and to compare their performance.
+func legacyDescribeTree(node *Node, opts *Options) string {
+	lines := []string{getTreeLine(node, opts)}
+	LE := getLE()
+
+	for _, child := range node.children {
+		next := legacyDescribeTree(child, opts)
+		for _, line := range strings.Split(next, LE) {
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	var nonBlankLines []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			nonBlankLines = append(nonBlankLines, line)
+		}
+	}
+
+	return strings.Join(nonBlankLines, LE)
+}
+
+// generateTree generates a tree input with the given number of lines and random depths, up to maxDepth.
+func generateTree(lines int, maxDepth int) string {
+	random := rand.New(rand.NewSource(1))
+	var builder strings.Builder
+	depth := 0
+	for i := 0; i < lines; i++ {
+		depth = random.Intn(min(depth+2, maxDepth+1))
+		builder.WriteString(strings.Repeat("  ", depth) + "node" + strconv.Itoa(i) + "\n")
+	}
+	return builder.String()
+}
+
+func TestRenderTree(t *testing.T) {
+	inputs := []string{generateTree(500, 8)}
+	files, _ := filepath.Glob("test_files/src/*")
+	for _, file := range files {
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, string(contents))
+	}
+
+	variants := [][]string{
+		{},
+		{"-c", "ascii"},
+		{"-D"},
+		{"-D", "-p", "-s"},
+		{"-r", "~", "-p"},
+		{"-s", "-a"},
+		{"-L", "2"},
+		{"-D", "-L", "1", "-p"},
+	}
+
+	for _, input := range inputs {
+		for _, args := range variants {
+			opts := DefaultOptions()
+			if _, err := parseArgs(opts, args, optionFlags); err != nil {
+				t.Fatal(err)
+			}
+			root := mustParseInput(t, input, opts)
+			transformTree(root, opts)
+
+			nodes := []*Node{root}
+			if len(root.children) > 0 {
+				nodes = append(nodes, root.children[0])
+				if len(root.children[0].children) > 0 {
+					nodes = append(nodes, root.children[0].children[0])
+				}
+			}
+			for _, node := range nodes {
+				actual := describeTree(node, opts)
+				want := legacyDescribeTree(node, opts)
+				if actual != want {
+					t.Errorf("describeTree(%q, %v)\nactual = %q\nwant   = %q", node.name, args, actual, want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkRenderTree(b *testing.B) {
+	opts := DefaultOptions()
+	root := mustParseInput(b, generateTree(100000, 12), opts)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		renderTree(io.Discard, root, opts)
+	}
+}
+
+func BenchmarkDescribeTree(b *testing.B) {
+	opts := DefaultOptions()
+	root := mustParseInput(b, generateTree(100000, 12), opts)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		describeTree(root, opts)
+	}
+}
+
+func BenchmarkLegacyDescribeTree(b *testing.B) {
+	opts := DefaultOptions()
+	root := mustParseInput(b, generateTree(100000, 12), opts)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		legacyDescribeTree(root, opts)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the snapshot files in test_files/snapshots")
+
+// snapshotVariants lists the options each input in test_files/src is rendered with. The output of an input
+// `NAME.txt` with a variant is compared with `NAME_snapshot<suffix>.txt` in test_files/snapshots.
+var snapshotVariants = []struct {
+	suffix string
+	args   []string
+}{
+	{"", nil},
+	{"_ascii", []string{"--charset", "ascii"}},
+	{"_full_path", []string{"--full-path"}},
+	{"_root_path", []string{"--root-path", "~", "--full-path"}},
+	{"_no_root", []string{"--no-root-dot"}},
+	{"_trailing_slash", []string{"--trailing-slash"}},
+	{"_summary", []string{"--summary-depths"}},
+	{"_max_depth", []string{"--max-depth", "1"}},
+	{"_sort", []string{"--sort", "name", "--dirs-first"}},
+	{"_collapse", []string{"--collapse"}},
+}
+
+// TestSnapshots renders every input in test_files/src with every variant, and compares the output with
+// its snapshot. Run `go test -run TestSnapshots -update` to write the snapshots instead.
+func TestSnapshots(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("test_files", "src", "*.txt"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("Error listing test_files/src: %v", err)
+	}
+
+	expected := map[string]bool{}
+	for _, input := range inputs {
+		contents, err := os.ReadFile(input)
+		if err != nil {
+			t.Fatalf("Error reading file: %v", err)
+		}
+		name := strings.TrimSuffix(filepath.Base(input), ".txt")
+
+		for _, variant := range snapshotVariants {
+			fileName := name + "_snapshot" + variant.suffix + ".txt"
+			expected[fileName] = true
+
+			t.Run(fileName, func(t *testing.T) {
+				opts := DefaultOptions()
+				if _, err := parseArgs(opts, variant.args, optionFlags); err != nil {
+					t.Fatalf("parseArgs(%v) error = %v", variant.args, err)
+				}
+				actual := renderSnapshot(t, string(contents), opts)
+				filePath := filepath.Join("test_files", "snapshots", fileName)
+
+				if *update {
+					if err := os.WriteFile(filePath, []byte(actual), 0o644); err != nil {
+						t.Fatalf("Error writing file: %v", err)
+					}
+					return
+				}
+
+				want, err := os.ReadFile(filePath)
+				if err != nil {
+					t.Fatalf("Error reading file: %v (run `go test -run TestSnapshots -update` to create it)", err)
+				}
+				if actual != string(want) {
+					t.Errorf("treelike %s -f %s\nactual = %q\nwant   = %q", strings.Join(variant.args, " "), input, actual, want)
+				}
+			})
+		}
+	}
+
+	snapshots, _ := filepath.Glob(filepath.Join("test_files", "snapshots", "*.txt"))
+	for _, snapshot := range snapshots {
+		if expected[filepath.Base(snapshot)] {
+			continue
+		}
+		if *update {
+			os.Remove(snapshot)
+			continue
+		}
+		t.Errorf("Snapshot %s has no matching input or variant", snapshot)
+	}
+}
+
+// renderSnapshot renders an input the same way as the treelike command, with Unix line endings. Options that
+// can be streamed are rendered both ways, which must give the same output.
+func renderSnapshot(t *testing.T, input string, opts *Options) string {
+	root := mustParseInput(t, input, opts)
+	transformTree(root, opts)
+	var output strings.Builder
+	if err := writeOutput(&output, root, opts); err != nil {
+		t.Fatalf("writeOutput() error = %v", err)
+	}
+
+	if canStream(opts) {
+		var streamed strings.Builder
+		if err := streamTree(strings.NewReader(input), &streamed, opts); err != nil {
+			t.Fatalf("streamTree() error = %v", err)
+		}
+		if streamed.String() != output.String() {
+			t.Errorf("streamTree()\nactual = %q\nwant   = %q", streamed.String(), output.String())
+		}
+	}
+
+	return strings.ReplaceAll(output.String(), LE_WIN, LE_UNIX)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+)
+
+// sortModes lists the valid values for the sort option.
+var sortModes = []string{"none", "name", "natural", "type", "reverse"}
+
+// sortTree recursively reorders the children of the given node according to the sort options.
+// Sorting is stable, so nodes that compare equal keep their input order.
+//
+// Parameters:
+//
+//	node - The root node of the tree to sort.
+//	opts - A pointer to an Options struct that specifies the sort mode and modifiers.
+func sortTree(node *Node, opts *Options) {
+	if opts.sortBy == "none" && !opts.dirsFirst {
+		return
+	}
+
+	slices.SortStableFunc(node.children, func(a, b *Node) int {
+		return compareNodes(a, b, opts)
+	})
+
+	for _, child := range node.children {
+		sortTree(child, opts)
+	}
+}
+
+// compareNodes compares two sibling nodes according to the sort options.
+// When the dirsFirst option is enabled, directories are placed before files regardless of the sort mode.
+//
+// Parameters:
+//
+//	a - The first node to compare.
+//	b - The second node to compare.
+//	opts - A pointer to an Options struct that specifies the sort mode and modifiers.
+//
+// Returns:
+//
+//	int - A negative number if a comes before b, a positive number if a comes after b, or 0 if they are equal.
+func compareNodes(a, b *Node, opts *Options) int {
+	if opts.dirsFirst && a.isDir() != b.isDir() {
+		if a.isDir() {
+			return -1
+		}
+		return 1
+	}
+
+	nameA, nameB := a.name, b.name
+	if opts.ignoreCase {
+		nameA, nameB = strings.ToLower(nameA), strings.ToLower(nameB)
+	}
+
+	switch opts.sortBy {
+	case "name":
+		return strings.Compare(nameA, nameB)
+	case "natural":
+		return naturalCompare(nameA, nameB)
+	case "type":
+		extA := path.Ext(strings.TrimSuffix(nameA, "/"))
+		extB := path.Ext(strings.TrimSuffix(nameB, "/"))
+		if c := strings.Compare(extA, extB); c != 0 {
+			return c
+		}
+		return strings.Compare(nameA, nameB)
+	case "reverse":
+		return strings.Compare(nameB, nameA)
+	}
+	return 0
+}
+
+// naturalCompare compares two strings, treating runs of digits as numbers so that
+// "file2" comes before "file10".
+//
+// Parameters:
+//
+//	a - The first string to compare.
+//	b - The second string to compare.
+//
+// Returns:
+//
+//	int - A negative number if a comes before b, a positive number if a comes after b, or 0 if they are equal.
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			numA, restA := splitDigits(a)
+			numB, restB := splitDigits(b)
+			trimmedA, trimmedB := strings.TrimLeft(numA, "0"), strings.TrimLeft(numB, "0")
+			if c := cmp.Compare(len(trimmedA), len(trimmedB)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(trimmedA, trimmedB); c != 0 {
+				return c
+			}
+			if c := cmp.Compare(len(numA), len(numB)); c != 0 {
+				return c
+			}
+			a, b = restA, restB
+			continue
+		}
+		if c := cmp.Compare(a[0], b[0]); c != 0 {
+			return c
+		}
+		a, b = a[1:], b[1:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// isDigit reports whether the given byte is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// splitDigits splits a string into its leading run of digits and the remainder.
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
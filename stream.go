@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// canStream reports whether the output for the given options can be rendered with streamTree, one
+// top-level subtree at a time. Sorting and merging duplicates compare the top-level nodes with each other,
+// so they need the whole tree. Streaming only bounds memory by the largest top-level subtree, see
+// streamTree.
+//
+// Parameters:
+//
+//	opts - A pointer to an Options struct that specifies the transformations to apply.
+//
+// Returns:
+//
+//	bool - True if the tree can be streamed, false otherwise.
+func canStream(opts *Options) bool {
+	return opts.sortBy == "none" && !opts.dirsFirst && !opts.mergeDupes
+}
+
+// streamInput reads a tree from the stdin or the single input file specified in the options, and writes
+// its output to w with streamTree.
+//
+// Parameters:
+//
+//	w - The writer to write the output to.
+//	opts - A pointer to an Options struct that specifies the input source and formatting options.
+//
+// Returns:
+//
+//	error - An error object if the input could not be read or the output could not be written, otherwise nil.
+func streamInput(w io.Writer, opts *Options) error {
+	source, input := "from stdin", io.Reader(os.Stdin)
+	if !opts.fromStdin {
+		name := opts.fromFiles[0]
+		file, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("error opening file %s: %w", name, err)
+		}
+		defer file.Close()
+		source, input = "file "+name, file
+	}
+
+	err := streamTree(input, w, opts)
+	var readErr *readError
+	var parseErr *parseError
+	if errors.As(err, &readErr) || errors.As(err, &parseErr) {
+		return fmt.Errorf("error reading %s: %w", source, err)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing output: %w", err)
+	}
+	return nil
+}
+
+// streamTree parses a tree from r and writes the same output as writeOutput to w, one top-level subtree at
+// a time. Each top-level subtree is transformed and summarized on its own as soon as it has been read, and
+// written once the next one is read, when it is known whether it is the last one. Only the subtree being
+// read and the one waiting to be written are kept in memory.
+//
+// Memory is bounded by the largest top-level subtree, not by the depth of the tree: the prefix of every
+// line below a node depends on whether that node is the last of its siblings, which is not known until its
+// whole subtree has been read, so no line of a subtree can be written earlier. An input with a single
+// top-level node is held in memory until it ends. The options must be ones for which canStream returns
+// true.
+//
+// Parameters:
+//
+//	r - The reader to read the tree structure from.
+//	w - The writer to write the output to.
+//	opts - A pointer to an Options struct that specifies formatting options.
+//
+// Returns:
+//
+//	error - An error object if the input could not be read or the output could not be written, otherwise nil.
+func streamTree(r io.Reader, w io.Writer, opts *Options) error {
+	renderer := &treeRenderer{w: bufio.NewWriter(w), opts: opts, le: getLE()}
+	renderer.child, renderer.lastChild, renderer.directory, renderer.empty = getPrefixes(opts)
+	top := !opts.rootDot
+	summary := Summary{}
+	started := false
+	rootPath := ""
+	var pending *Node
+
+	start := func(root *Node) {
+		if started {
+			return
+		}
+		started = true
+		renderer.writeLine(getTreeLine(root, opts))
+		if opts.fullPath {
+			rootPath = getPathPrefix(root)
+		}
+	}
+
+	root, err := parseEvents(r, opts, func(event parseEvent) error {
+		node := event.node
+		parent := node.parent
+		if event.kind == EVENT_ENTER {
+			if parent.parent == nil {
+				start(parent)
+			} else {
+				parent.children = append(parent.children, node)
+			}
+			return nil
+		}
+		if parent.parent != nil {
+			return nil
+		}
+
+		parent.children = []*Node{node}
+		transformTree(parent, opts)
+		if len(parent.children) == 0 {
+			return nil
+		}
+		summary.add(summarizeTree(parent))
+		if pending != nil {
+			renderer.renderNode(pending, false, top, rootPath)
+		}
+		pending = parent.children[0]
+		parent.children = []*Node{}
+		return nil
+	})
+	if err != nil {
+		renderer.w.Flush()
+		return err
+	}
+
+	start(root)
+	if pending != nil {
+		renderer.renderNode(pending, true, top, rootPath)
+	}
+	if renderer.lines == 0 {
+		renderer.w.WriteString(renderer.le)
+	}
+	if opts.summary {
+		renderer.w.WriteString(renderer.le + describeSummary(summary, opts) + renderer.le)
+	}
+	return renderer.w.Flush()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStreamTree(t *testing.T) {
+	inputs := []string{generateTree(500, 8), "", "a\n"}
+	files, _ := filepath.Glob("test_files/src/*")
+	for _, file := range files {
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, string(contents))
+	}
+
+	variants := [][]string{
+		{},
+		{"-c", "ascii", "-s"},
+		{"-D"},
+		{"-D", "-p", "-S"},
+		{"-r", "~", "-p", "--summary-depths"},
+		{"-L", "2", "-S"},
+		{"-X", "*1*", "-S"},
+		{"-P", "*5*", "-D"},
+		{"--collapse", "-s"},
+		{"-D", "-X", "*"},
+	}
+
+	for _, input := range inputs {
+		for _, args := range variants {
+			opts := DefaultOptions()
+			if _, err := parseArgs(opts, args, optionFlags); err != nil {
+				t.Fatal(err)
+			}
+			if !canStream(opts) {
+				t.Fatalf("canStream(%v) = false", args)
+			}
+
+			var actual strings.Builder
+			if err := streamTree(strings.NewReader(input), &actual, opts); err != nil {
+				t.Fatalf("streamTree() error = %v", err)
+			}
+			root := mustParseInput(t, input, opts)
+			transformTree(root, opts)
+			var want strings.Builder
+			if err := writeOutput(&want, root, opts); err != nil {
+				t.Fatal(err)
+			}
+			if actual.String() != want.String() {
+				t.Errorf("streamTree(%v)\nactual = %q\nwant   = %q", args, actual.String(), want.String())
+			}
+		}
+	}
+}
+
+// progressReader returns its input one line per Read, and records how many bytes had been written to
+// output before the last line was returned.
+type progressReader struct {
+	lines   []string
+	output  *strings.Builder
+	written int
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	if len(r.lines) == 0 {
+		return 0, io.EOF
+	}
+	if len(r.lines) == 1 {
+		r.written = r.output.Len()
+	}
+	n := copy(p, r.lines[0])
+	r.lines[0] = r.lines[0][n:]
+	if r.lines[0] == "" {
+		r.lines = r.lines[1:]
+	}
+	return n, nil
+}
+
+func TestStreamTreeRetention(t *testing.T) {
+	var many, single strings.Builder
+	single.WriteString("project\n")
+	for i := 0; i < 20; i++ {
+		many.WriteString("dir" + strconv.Itoa(i) + "\n")
+		single.WriteString("  dir" + strconv.Itoa(i) + "\n")
+		for j := 0; j < 100; j++ {
+			many.WriteString("  file" + strconv.Itoa(j) + "\n")
+			single.WriteString("    file" + strconv.Itoa(j) + "\n")
+		}
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		check func(written int, total int) bool
+	}{
+		// all but the last two top-level subtrees, and the output buffer, are written before the end
+		{"many top-level nodes", many.String(), func(written int, total int) bool { return written > total*9/10-4096 }},
+		// every line depends on whether the single top-level node is the last one
+		{"single top-level node", single.String(), func(written int, total int) bool { return written == 0 }},
+	}
+
+	for _, test := range tests {
+		var output strings.Builder
+		lines := strings.SplitAfter(strings.TrimSuffix(test.input, "\n"), "\n")
+		reader := &progressReader{lines: lines, output: &output}
+		if err := streamTree(reader, &output, DefaultOptions()); err != nil {
+			t.Fatal(err)
+		}
+		if !test.check(reader.written, output.Len()) {
+			t.Errorf("streamTree(%s) wrote %d of %d bytes before the end of the input", test.name, reader.written, output.Len())
+		}
+	}
+}
+
+func TestCanStream(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected bool
+	}{
+		{[]string{"-L", "2", "--collapse", "-X", "*.go"}, true},
+		{[]string{"--sort", "name"}, false},
+		{[]string{"--dirs-first"}, false},
+		{[]string{"--merge-duplicates"}, false},
+	}
+
+	for _, test := range tests {
+		opts := DefaultOptions()
+		if _, err := parseArgs(opts, test.args, optionFlags); err != nil {
+			t.Fatal(err)
+		}
+		if actual := canStream(opts); actual != test.expected {
+			t.Errorf("canStream(%v)\nactual = %v\nwant   = %v", test.args, actual, test.expected)
+		}
+	}
+}
+
+func BenchmarkStreamTree(b *testing.B) {
+	input := generateTree(100000, 12)
+	opts := DefaultOptions()
+	for i := 0; i < b.N; i++ {
+		streamTree(strings.NewReader(input), io.Discard, opts)
+	}
+}
+
+func BenchmarkParseAndRenderTree(b *testing.B) {
+	input := generateTree(100000, 12)
+	opts := DefaultOptions()
+	for i := 0; i < b.N; i++ {
+		root := mustParseInput(b, input, opts)
+		renderTree(io.Discard, root, opts)
+	}
+}
@@ -4,26 +4,58 @@ import "strings"
 
 type Options struct {
 	fromStdin     bool
-	fromFile      string
+	fromFiles     []string
+	mergeFiles    bool
+	watch         bool
+	outFile       string
+	skipUnchanged bool
 	extra         strings.Builder
 	charset       string
 	trailingSlash bool
 	fullPath      bool
 	rootDot       bool
 	rootPath      string
+	attributes    bool
+	summary       bool
+	summaryDepths bool
+	sortBy        string
+	dirsFirst     bool
+	ignoreCase    bool
+	maxDepth      int
+	includes      []*Pattern
+	excludes      []*Pattern
+	collapse      bool
+	mergeDupes    bool
+	emptyNames    string
 }
 
 // default options factory
 func DefaultOptions() *Options {
 	return &Options{
 		fromStdin:     false,
-		fromFile:      "",
+		fromFiles:     []string{},
+		mergeFiles:    false,
+		watch:         false,
+		outFile:       "",
+		skipUnchanged: false,
 		extra:         strings.Builder{},
 		charset:       "utf-8",
 		trailingSlash: false,
 		fullPath:      false,
 		rootDot:       true,
 		rootPath:      ".",
+		attributes:    false,
+		summary:       false,
+		summaryDepths: false,
+		sortBy:        "none",
+		dirsFirst:     false,
+		ignoreCase:    false,
+		maxDepth:      0,
+		includes:      []*Pattern{},
+		excludes:      []*Pattern{},
+		collapse:      false,
+		mergeDupes:    false,
+		emptyNames:    "skip",
 	}
 }
 
@@ -36,4 +68,26 @@ type Node struct {
 	children []*Node
 	// parent of node
 	parent *Node
+	// attributes of node, parsed from a trailing `{key=value, ...}` block
+	attrs map[string]string
+	// kind of node, either explicit or inferred from its children
+	kind NodeKind
+	// number of descendants hidden in place of this node, set only on elision nodes
+	hidden int
+}
+
+// isDir reports whether the node is a directory. A node marked explicitly as a directory (with a trailing
+// `/` or a `type=dir` attribute) or as a file (with `type=file`) keeps that kind; otherwise it is a
+// directory if it has any children.
+func (n *Node) isDir() bool {
+	if n.kind != KIND_AUTO {
+		return n.kind == KIND_DIR
+	}
+	return len(n.children) > 0
+}
+
+// attr returns the value of the attribute with the given key, and whether it was set on the node.
+func (n *Node) attr(key string) (string, bool) {
+	value, ok := n.attrs[key]
+	return value, ok
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Summary holds the number of directories and files in a tree.
+type Summary struct {
+	// number of directories in the tree
+	dirs int
+	// number of files in the tree
+	files int
+	// counts per level, where index 0 is the first level below the root
+	levels []Summary
+}
+
+// summarizeTree counts the directories and files below the given node, both in total and per level.
+// The node itself is not counted, the same as the root directory in the Unix `tree` command.
+//
+// Parameters:
+//
+//	node - The root node of the tree to summarize.
+//
+// Returns:
+//
+//	Summary - The directory and file counts of the tree.
+func summarizeTree(node *Node) Summary {
+	summary := Summary{}
+	summarizeLevel(node, 0, &summary)
+	return summary
+}
+
+// summarizeLevel adds the children of the given node, and recursively their descendants, to the summary.
+//
+// Parameters:
+//
+//	node - The node whose children to count.
+//	level - The level of the node's children, where 0 is the first level below the root.
+//	summary - The summary to add the counts to.
+func summarizeLevel(node *Node, level int, summary *Summary) {
+	for _, child := range node.children {
+		if child.hidden > 0 {
+			continue
+		}
+		if len(summary.levels) <= level {
+			summary.levels = append(summary.levels, Summary{})
+		}
+		if child.isDir() {
+			summary.dirs++
+			summary.levels[level].dirs++
+		} else {
+			summary.files++
+			summary.levels[level].files++
+		}
+		summarizeLevel(child, level+1, summary)
+	}
+}
+
+// add adds the counts of another summary to the summary, level by level.
+//
+// Parameters:
+//
+//	other - The summary to add.
+func (s *Summary) add(other Summary) {
+	s.dirs += other.dirs
+	s.files += other.files
+	for i, level := range other.levels {
+		if len(s.levels) <= i {
+			s.levels = append(s.levels, Summary{})
+		}
+		s.levels[i].dirs += level.dirs
+		s.levels[i].files += level.files
+	}
+}
+
+// maxDepth returns the depth of the deepest level in the summary, or 0 for an empty tree.
+func (s Summary) maxDepth() int {
+	return len(s.levels)
+}
+
+// describeSummary generates the summary footer for a tree, such as `3 directories, 8 files`.
+// If the summaryDepths option is enabled, the counts of each level and the maximum depth are
+// listed below the totals.
+//
+// Parameters:
+//
+//	summary - The summary to describe.
+//	opts - A pointer to an Options struct that specifies formatting options.
+//
+// Returns:
+//
+//	string - The summary footer.
+func describeSummary(summary Summary, opts *Options) string {
+	LE := getLE()
+	lines := []string{describeCounts(summary)}
+
+	if opts.summaryDepths {
+		for i, level := range summary.levels {
+			lines = append(lines, fmt.Sprintf("depth %d: %s", i+1, describeCounts(level)))
+		}
+		lines = append(lines, fmt.Sprintf("max depth: %d", summary.maxDepth()))
+	}
+
+	return strings.Join(lines, LE)
+}
+
+// describeCounts formats the directory and file counts of a summary, such as `1 directory, 2 files`.
+func describeCounts(summary Summary) string {
+	return pluralize(summary.dirs, "directory", "directories") + ", " + pluralize(summary.files, "file", "files")
+}
+
+// pluralize formats a count followed by the singular or plural form of a noun.
+func pluralize(count int, singular string, plural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
@@ -0,0 +1,31 @@
+//go:build !linux && !darwin
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// terminal is the controlling terminal of the process. Raw mode is only implemented on Linux and macOS, so
+// openTerminal always fails on other platforms.
+type terminal struct {
+	*os.File
+	resized     chan os.Signal
+	interrupted chan os.Signal
+}
+
+// openTerminal returns an error, as the browser is not supported on this platform.
+func openTerminal() (*terminal, error) {
+	return nil, errors.New("browse is only supported on Linux and macOS")
+}
+
+// size returns the default size of a terminal.
+func (t *terminal) size() (int, int) {
+	return 80, 24
+}
+
+// close closes the terminal.
+func (t *terminal) close() error {
+	return t.Close()
+}
@@ -0,0 +1,87 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// terminal is the controlling terminal of the process, in raw mode, so each key is read as it is pressed.
+type terminal struct {
+	*os.File
+	// settings of the terminal before it was switched to raw mode
+	state syscall.Termios
+	// receives a signal when the terminal is resized
+	resized chan os.Signal
+	// receives a signal when the process is asked to stop, so the terminal can be restored first
+	interrupted chan os.Signal
+}
+
+// winsize is the size of a terminal, as returned by the TIOCGWINSZ ioctl.
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+// openTerminal opens the controlling terminal and switches it to raw mode. It is used rather than stdin
+// and stdout, so a tree can be piped to the program while it is browsed.
+//
+// Returns:
+//
+//	*terminal - The terminal, to be closed to restore its settings.
+//	error - An error object if there is no terminal or it could not be switched to raw mode, otherwise nil.
+func openTerminal() (*terminal, error) {
+	file, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	t := &terminal{File: file, resized: make(chan os.Signal, 1), interrupted: make(chan os.Signal, 1)}
+	if err := t.ioctl(IOCTL_GET_TERMIOS, unsafe.Pointer(&t.state)); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	raw := t.state
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := t.ioctl(IOCTL_SET_TERMIOS, unsafe.Pointer(&raw)); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	signal.Notify(t.resized, syscall.SIGWINCH)
+	signal.Notify(t.interrupted, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	return t, nil
+}
+
+// size returns the number of columns and lines of the terminal, or 80 by 24 if it can not be read.
+func (t *terminal) size() (int, int) {
+	var size winsize
+	if err := t.ioctl(syscall.TIOCGWINSZ, unsafe.Pointer(&size)); err != nil || size.cols == 0 {
+		return 80, 24
+	}
+	return int(size.cols), int(size.rows)
+}
+
+// close restores the settings of the terminal and closes it.
+func (t *terminal) close() error {
+	signal.Stop(t.resized)
+	signal.Stop(t.interrupted)
+	t.ioctl(IOCTL_SET_TERMIOS, unsafe.Pointer(&t.state))
+	return t.Close()
+}
+
+// ioctl runs an ioctl request on the terminal.
+func (t *terminal) ioctl(request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, t.Fd(), request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import "strings"
+
+// limitDepth cuts off the branches of the tree that are deeper than maxDepth. The children of each node
+// at the maximum depth are replaced by a single elision node, which reports how many descendants were hidden.
+//
+// Parameters:
+//
+//	node - The root node of the tree to limit.
+//	maxDepth - The maximum number of levels to keep below the root. 0 means no limit.
+func limitDepth(node *Node, maxDepth int) {
+	if maxDepth <= 0 {
+		return
+	}
+	limitLevel(node, 0, maxDepth)
+}
+
+// limitLevel recursively applies the depth limit to the given node and its descendants.
+//
+// Parameters:
+//
+//	node - The node to limit.
+//	level - The level of the node, where 0 is the root.
+//	maxDepth - The maximum number of levels to keep below the root.
+func limitLevel(node *Node, level int, maxDepth int) {
+	if level < maxDepth {
+		for _, child := range node.children {
+			limitLevel(child, level+1, maxDepth)
+		}
+		return
+	}
+
+	if len(node.children) == 0 {
+		return
+	}
+	elision := &Node{name: "", depth: node.depth + 1, children: []*Node{}, parent: node, kind: KIND_FILE, hidden: countDescendants(node)}
+	node.children = []*Node{elision}
+}
+
+// countDescendants returns the number of nodes below the given node.
+//
+// Parameters:
+//
+//	node - The node whose descendants to count.
+//
+// Returns:
+//
+//	int - The number of descendants.
+func countDescendants(node *Node) int {
+	count := 0
+	for _, child := range node.children {
+		count += 1 + countDescendants(child)
+	}
+	return count
+}
+
+// collapseTree merges chains of directories that each contain a single directory into one node, such as
+// `src/main/java/com/acme`. The root node itself is never merged into its children. The attributes of the
+// merged nodes are combined, and when several of them set the same key, the deepest one wins, as the
+// collapsed line ends with its name.
+//
+// Parameters:
+//
+//	node - The root node of the tree to collapse.
+func collapseTree(node *Node) {
+	for _, child := range node.children {
+		for len(child.children) == 1 && child.children[0].isDir() {
+			only := child.children[0]
+			child.name = strings.TrimSuffix(child.name, "/") + "/" + only.name
+			child.kind = KIND_DIR
+			for attrKey, value := range only.attrs {
+				if child.attrs == nil {
+					child.attrs = map[string]string{}
+				}
+				child.attrs[attrKey] = value
+			}
+			child.children = only.children
+			for _, grandchild := range child.children {
+				grandchild.parent = child
+			}
+		}
+		collapseTree(child)
+	}
+}
+
+// mergeDuplicates merges sibling nodes with the same name into the first of them, recursively. The children
+// of the merged nodes are appended to the first node's children, and their attributes are added to its
+// attributes, without overriding the ones it already has.
+//
+// Parameters:
+//
+//	node - The root node of the tree to merge.
+func mergeDuplicates(node *Node) {
+	var merged []*Node
+	seen := map[string]*Node{}
+	for _, child := range node.children {
+		key := strings.TrimSuffix(child.name, "/")
+		first, ok := seen[key]
+		if !ok {
+			seen[key] = child
+			merged = append(merged, child)
+			continue
+		}
+		for _, grandchild := range child.children {
+			grandchild.parent = first
+		}
+		first.children = append(first.children, child.children...)
+		for attrKey, value := range child.attrs {
+			if _, exists := first.attrs[attrKey]; !exists {
+				if first.attrs == nil {
+					first.attrs = map[string]string{}
+				}
+				first.attrs[attrKey] = value
+			}
+		}
+		if child.isDir() {
+			first.kind = KIND_DIR
+		}
+	}
+	node.children = merged
+
+	for _, child := range node.children {
+		mergeDuplicates(child)
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"os"
 	"strings"
 	"testing"
 )
@@ -10,7 +9,7 @@ func TestDescribeTree(t *testing.T) {
 	input := "root\n    child1\n    child2\n        grandchild1\n"
 	opts := DefaultOptions()
 	opts.rootDot = true
-	root := parseInput(input, opts)
+	root := mustParseInput(t, input, opts)
 	result := describeTree(root, opts)
 
 	expected := ".\n└── root\n    ├── child1\n    └── child2\n        └── grandchild1"
@@ -74,7 +73,7 @@ func TestMultiRoot(t *testing.T) {
 	input := "I\n am\n  a\n   superhero!\na\n what?\na\n superhero!\n"
 	opts := DefaultOptions()
 	opts.rootDot = true
-	root := parseInput(input, opts)
+	root := mustParseInput(t, input, opts)
 	result := describeTree(root, opts)
 	expected := ".\n├── I\n│   └── am\n│       └── a\n│           └── superhero!\n├── a\n│   └── what?\n└── a\n    └── superhero!"
 	if result != expected {
@@ -86,7 +85,7 @@ func TestWinNewLines(t *testing.T) {
 	input := "root\r\n    child1\r\n    child2\r\n        grandchild1\r\n"
 	opts := DefaultOptions()
 	opts.rootDot = true
-	root := parseInput(input, opts)
+	root := mustParseInput(t, input, opts)
 	result := describeTree(root, opts)
 	expected := ".\n└── root\n    ├── child1\n    └── child2\n        └── grandchild1"
 	if result != expected {
@@ -94,70 +93,223 @@ func TestWinNewLines(t *testing.T) {
 	}
 }
 
-func TestSnapshots(t *testing.T) {
-	// list all files in test_files/src
-	// cwd := os.Getenv("PWD")
-	dirPath := strings.Join([]string{"test_files", "src"}, string(os.PathSeparator))
+func TestDescribeSummary(t *testing.T) {
+	input := "usr\n  local/\n  bin\n    sh\n    bash\n  sbin\n    sysctl\n"
+	opts := DefaultOptions()
+	summary := summarizeTree(mustParseInput(t, input, opts))
 
-	dir, err := os.Open(dirPath)
+	result := describeSummary(summary, opts)
+	expected := "4 directories, 3 files"
+	if result != expected {
+		t.Errorf("describeSummary()\n actual = %q\nwant   = %q", result, expected)
+	}
 
-	if err != nil {
-		t.Errorf("Error opening directory: %v", err)
+	opts.summaryDepths = true
+	result = describeSummary(summary, opts)
+	expected = "4 directories, 3 files\ndepth 1: 1 directory, 0 files\ndepth 2: 3 directories, 0 files\ndepth 3: 0 directories, 3 files\nmax depth: 3"
+	if result != expected {
+		t.Errorf("describeSummary()\n actual = %q\nwant   = %q", result, expected)
 	}
 
-	entries, err := dir.Readdir(-1)
-	if err != nil {
-		t.Errorf("Error reading directory: %v", err)
+	result = describeSummary(summarizeTree(mustParseInput(t, "file\n", opts)), DefaultOptions())
+	expected = "0 directories, 1 file"
+	if result != expected {
+		t.Errorf("describeSummary()\n actual = %q\nwant   = %q", result, expected)
 	}
+}
 
-	defer dir.Close()
+func TestSortTree(t *testing.T) {
+	input := "b\n  x.txt\nfile10.go\nA/\nfile2.md\na\n"
+	tests := []struct {
+		sortBy     string
+		dirsFirst  bool
+		ignoreCase bool
+		expected   []string
+	}{
+		{"none", false, false, []string{"b", "file10.go", "A/", "file2.md", "a"}},
+		{"name", false, false, []string{"A/", "a", "b", "file10.go", "file2.md"}},
+		{"natural", false, false, []string{"A/", "a", "b", "file2.md", "file10.go"}},
+		{"type", false, false, []string{"A/", "a", "b", "file10.go", "file2.md"}},
+		{"reverse", false, false, []string{"file2.md", "file10.go", "b", "a", "A/"}},
+		{"name", true, false, []string{"A/", "b", "a", "file10.go", "file2.md"}},
+		{"none", true, false, []string{"b", "A/", "file10.go", "file2.md", "a"}},
+		{"name", false, true, []string{"a", "A/", "b", "file10.go", "file2.md"}},
+	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
+	for _, test := range tests {
+		opts := DefaultOptions()
+		opts.sortBy = test.sortBy
+		opts.dirsFirst = test.dirsFirst
+		opts.ignoreCase = test.ignoreCase
+		root := mustParseInput(t, input, opts)
+		sortTree(root, opts)
 
-		t.Logf("Testing %s", entry.Name())
+		var names []string
+		for _, child := range root.children {
+			names = append(names, child.name)
+		}
+		if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("sortTree(%s, dirsFirst=%v, ignoreCase=%v)\n actual = %v\nwant   = %v", test.sortBy, test.dirsFirst, test.ignoreCase, names, test.expected)
+		}
+	}
+}
 
-		tests := make(map[string]*Options)
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"file02", "file2", 1},
+		{"v1.10.0", "v1.9.0", 1},
+		{"a", "a", 0},
+		{"a", "ab", -1},
+	}
 
-		tests[""] = DefaultOptions()
-		tests["_ascii"] = DefaultOptions()
-		tests["_ascii"].charset = "ascii"
-		tests["_full_path"] = DefaultOptions()
-		tests["_full_path"].fullPath = true
-		tests["_root_path"] = DefaultOptions()
-		tests["_root_path"].rootPath = "~"
-		tests["_root_path"].fullPath = true
-		tests["_no_root"] = DefaultOptions()
-		tests["_no_root"].rootDot = false
-		tests["_trailing_slash"] = DefaultOptions()
-		tests["_trailing_slash"].trailingSlash = true
+	for _, test := range tests {
+		result := naturalCompare(test.a, test.b)
+		if result != test.expected {
+			t.Errorf("naturalCompare(%q, %q)\n actual = %d\nwant   = %d", test.a, test.b, result, test.expected)
+		}
+	}
+}
 
-		contents, err := os.ReadFile(dirPath + string(os.PathSeparator) + entry.Name())
+func TestLimitDepth(t *testing.T) {
+	input := "usr\n  local\n  bin\n    sh\n    bash\n  sbin\n    sysctl\n"
+	tests := []struct {
+		maxDepth int
+		charset  string
+		expected string
+	}{
+		{0, "utf-8", ".\n└── usr\n    ├── local\n    ├── bin\n    │   ├── sh\n    │   └── bash\n    └── sbin\n        └── sysctl"},
+		{1, "utf-8", ".\n└── usr\n    └── … (6 more)"},
+		{2, "utf-8", ".\n└── usr\n    ├── local\n    ├── bin\n    │   └── … (2 more)\n    └── sbin\n        └── … (1 more)"},
+		{2, "ascii", ".\n`-- usr\n    |-- local\n    |-- bin\n    |   `-- ... (2 more)\n    `-- sbin\n        `-- ... (1 more)"},
+	}
 
-		if err != nil {
-			t.Errorf("Error reading file: %v", err)
+	for _, test := range tests {
+		opts := DefaultOptions()
+		opts.charset = test.charset
+		opts.maxDepth = test.maxDepth
+		root := mustParseInput(t, input, opts)
+		limitDepth(root, opts.maxDepth)
+		result := describeTree(root, opts)
+		if result != test.expected {
+			t.Errorf("describeTree(maxDepth=%d)\n actual = %q\nwant   = %q", test.maxDepth, result, test.expected)
 		}
+	}
+}
 
-		for suffix, opts := range tests {
-			fileName := entry.Name()[:len(entry.Name())-4] + "_snapshot" + suffix + ".txt"
-			filePath := strings.Join([]string{"test_files", "snapshots", fileName}, string(os.PathSeparator))
-
-			t.Logf("Testing snapshot %s", fileName)
+func TestFilterTree(t *testing.T) {
+	input := "api\n  user.proto\n  user.pb.go\n  legacy {deprecated=true}\n    old.proto\nweb\n  main.go\n"
+	tests := []struct {
+		includes []string
+		excludes []string
+		expected string
+	}{
+		{nil, nil, ".\n├── api\n│   ├── user.proto\n│   ├── user.pb.go\n│   └── legacy\n│       └── old.proto\n└── web\n    └── main.go"},
+		{[]string{"*.proto"}, nil, ".\n└── api\n    ├── user.proto\n    └── legacy\n        └── old.proto"},
+		{[]string{"*.proto"}, []string{"attr:deprecated"}, ".\n└── api\n    └── user.proto"},
+		{[]string{"web"}, nil, ".\n└── web\n    └── main.go"},
+		{nil, []string{"re:\\.go$"}, ".\n├── api\n│   ├── user.proto\n│   └── legacy\n│       └── old.proto\n└── web"},
+		{[]string{"api/legacy/*"}, nil, ".\n└── api\n    └── legacy\n        └── old.proto"},
+	}
 
-			want, err := os.ReadFile(filePath)
+	for _, test := range tests {
+		opts := DefaultOptions()
+		for _, include := range test.includes {
+			pattern, err := parsePattern(include)
 			if err != nil {
-				t.Errorf("Error reading file: %v", err)
-				break
+				t.Fatalf("parsePattern(%q) failed: %v", include, err)
 			}
-			actual := describeTree(parseInput(string(contents), opts), opts)
-
-			if actual+"\n" != string(want) {
-				t.Errorf("describeTree()\nactual = %q\nwant   = %q\n file %v", actual, want, filePath)
-				break
+			opts.includes = append(opts.includes, pattern)
+		}
+		for _, exclude := range test.excludes {
+			pattern, err := parsePattern(exclude)
+			if err != nil {
+				t.Fatalf("parsePattern(%q) failed: %v", exclude, err)
 			}
+			opts.excludes = append(opts.excludes, pattern)
+		}
+		root := mustParseInput(t, input, opts)
+		filterTree(root, opts)
+		result := describeTree(root, opts)
+		if result != test.expected {
+			t.Errorf("filterTree(include=%v, exclude=%v)\n actual = %q\nwant   = %q", test.includes, test.excludes, result, test.expected)
+		}
+	}
+}
+
+func TestParsePatternErrors(t *testing.T) {
+	for _, pattern := range []string{"re:(", "[", "attr:"} {
+		if _, err := parsePattern(pattern); err == nil {
+			t.Errorf("parsePattern(%q) expected an error", pattern)
 		}
 	}
+}
+
+func TestCollapseTree(t *testing.T) {
+	input := "src\n  main\n    java\n      com\n        acme\n          App.java\n          util\n            Strings.java\nREADME.md\ndocs/\n  empty/\n"
+	opts := DefaultOptions()
+	opts.fullPath = true
+	root := mustParseInput(t, input, opts)
+	collapseTree(root)
+
+	result := describeTree(root, opts)
+	expected := ".\n├── ./src/main/java/com/acme\n│   ├── ./src/main/java/com/acme/App.java\n│   └── ./src/main/java/com/acme/util\n│       └── ./src/main/java/com/acme/util/Strings.java\n├── ./README.md\n└── ./docs/empty/"
+	if result != expected {
+		t.Errorf("collapseTree()\n actual = %q\nwant   = %q", result, expected)
+	}
+}
 
+func TestCollapseTreeAttributes(t *testing.T) {
+	input := "src {owner=@core, lang=go}\n  cmd {owner=@cli}\n    tool\n      main.go\nlib\n  util {owner=@web}\n    x.go\n"
+	opts := DefaultOptions()
+	opts.attributes = true
+	root := mustParseInput(t, input, opts)
+	collapseTree(root)
+
+	result := describeTree(root, opts)
+	expected := ".\n├── src/cmd/tool {lang=go, owner=@cli}\n│   └── main.go\n└── lib/util {owner=@web}\n    └── x.go"
+	if result != expected {
+		t.Errorf("collapseTree()\n actual = %q\nwant   = %q", result, expected)
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	input := "a\n what?\na {owner=@web}\n superhero!\nsrc/\n  lib\n    x.go\nsrc {owner=@core}\n  lib\n    y.go\n"
+	opts := DefaultOptions()
+	opts.attributes = true
+	root := mustParseInput(t, input, opts)
+	mergeDuplicates(root)
+
+	result := describeTree(root, opts)
+	expected := ".\n├── a {owner=@web}\n│   ├── what?\n│   └── superhero!\n└── src/ {owner=@core}\n    └── lib\n        ├── x.go\n        └── y.go"
+	if result != expected {
+		t.Errorf("mergeDuplicates()\n actual = %q\nwant   = %q", result, expected)
+	}
+}
+
+func TestFullPathSlashes(t *testing.T) {
+	tests := []struct {
+		input    string
+		args     []string
+		expected string
+	}{
+		{"a/\n  /b\n  //\n    c\n", []string{"-p"}, ".\n└── ./a/\n    ├── ./a/b\n    └── ./a/\n        └── ./a/c"},
+		{"etc\n  hosts\n", []string{"-p", "-r", "/"}, "/\n└── /etc\n    └── /etc/hosts"},
+	}
+
+	for _, test := range tests {
+		opts := DefaultOptions()
+		if _, err := parseArgs(opts, test.args, optionFlags); err != nil {
+			t.Fatal(err)
+		}
+		result := describeTree(mustParseInput(t, test.input, opts), opts)
+		if result != test.expected {
+			t.Errorf("describeTree(%q, %v)\nactual = %q\nwant   = %q", test.input, test.args, result, test.expected)
+		}
+	}
 }
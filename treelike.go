@@ -2,17 +2,138 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 )
 
 func main() {
-	opts := getOpts()
+	if len(os.Args) > 1 {
+		for _, command := range commands {
+			if os.Args[1] == command.name {
+				os.Exit(command.run(os.Args[2:]))
+			}
+		}
+	}
 
-	input, err, code := parseRawInput(opts)
-	if err != nil {
+	opts := getOpts(os.Args[1:])
+
+	if opts.watch {
+		if err := watchInput(os.Stdout, opts, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "treelike: %s\n", err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	if opts.outFile != "" {
+		if _, err, code := renderToFile(opts); err != nil {
+			fmt.Println(err)
+			os.Exit(code)
+		}
+		return
+	}
+
+	if err, code := renderInput(os.Stdout, opts); err != nil {
 		fmt.Println(err)
 		os.Exit(code)
 	}
-	node := parseInput(input.String(), opts)
-	fmt.Println(describeTree(node, opts))
+}
+
+// renderInput reads the input specified in the options, and writes its output to w. Input from stdin or a
+// single file is streamed when the options allow it; otherwise the whole tree is read and transformed first.
+//
+// Parameters:
+//
+//	w - The writer to write the output to.
+//	opts - A pointer to an Options struct that specifies the input source and formatting options.
+//
+// Returns:
+//
+//	error - An error object if an error occurred, otherwise nil.
+//	int - An error code: 0 for success, 1 for reading and writing errors, 2 for missing input source.
+func renderInput(w io.Writer, opts *Options) (error, int) {
+	if canStream(opts) && (opts.fromStdin || len(opts.fromFiles) == 1) {
+		if err := streamInput(w, opts); err != nil {
+			return err, 1
+		}
+		return nil, 0
+	}
+
+	node, err, code := readInput(opts)
+	if err != nil {
+		return err, code
+	}
+	transformTree(node, opts)
+	if err := writeOutput(w, node, opts); err != nil {
+		return fmt.Errorf("error writing output: %w", err), 1
+	}
+	return nil, 0
+}
+
+// writeOutput writes the same output as describeOutput to w, followed by a line ending. The tree is
+// streamed with renderTree rather than built in memory first.
+//
+// Parameters:
+//
+//	w - The writer to write the output to.
+//	node - The root node of the tree to describe.
+//	opts - A pointer to an Options struct that specifies formatting options.
+//
+// Returns:
+//
+//	error - An error object if writing failed, otherwise nil.
+func writeOutput(w io.Writer, node *Node, opts *Options) error {
+	LE := getLE()
+	lines, err := renderTree(w, node, opts)
+	if err != nil {
+		return err
+	}
+	if lines == 0 {
+		if _, err := io.WriteString(w, LE); err != nil {
+			return err
+		}
+	}
+	if opts.summary {
+		_, err = io.WriteString(w, LE+describeSummary(summarizeTree(node), opts)+LE)
+	}
+	return err
+}
+
+// describeOutput generates the full output for a tree: the tree itself, followed by the summary if the
+// summary option is enabled.
+//
+// Parameters:
+//
+//	node - The root node of the tree to describe.
+//	opts - A pointer to an Options struct that specifies formatting options.
+//
+// Returns:
+//
+//	string - The output for the tree.
+func describeOutput(node *Node, opts *Options) string {
+	output := describeTree(node, opts)
+	if opts.summary {
+		LE := getLE()
+		output += LE + LE + describeSummary(summarizeTree(node), opts)
+	}
+	return output
+}
+
+// transformTree applies the tree transformations enabled in the options: merging duplicates, filtering,
+// collapsing, sorting and limiting the depth, in that order.
+//
+// Parameters:
+//
+//	node - The root node of the tree to transform.
+//	opts - A pointer to an Options struct that specifies the transformations to apply.
+func transformTree(node *Node, opts *Options) {
+	if opts.mergeDupes {
+		mergeDuplicates(node)
+	}
+	filterTree(node, opts)
+	if opts.collapse {
+		collapseTree(node)
+	}
+	sortTree(node, opts)
+	limitDepth(node, opts.maxDepth)
 }
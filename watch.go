@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"hash/fnv"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WATCH_INTERVAL is how often the input files are checked for changes in watch mode.
+const WATCH_INTERVAL = 200 * time.Millisecond
+
+// watchInput renders the input files specified in the options to w, and renders them again whenever one of
+// them changes. Each render clears the terminal first. If an output file is given with --out, the file is
+// written instead, and the change is reported on w. Files are polled for changes to their contents, which
+// works the same on every platform, and with editors that replace files when saving them. Errors while
+// reading the input, such as a file that is being written, are displayed in place of the tree until the
+// next change.
+//
+// Parameters:
+//
+//	w - The writer to write the output to.
+//	opts - A pointer to an Options struct that specifies the input files and formatting options.
+//	stop - A channel that stops watching when it is closed, or nil to watch until the program is interrupted.
+//
+// Returns:
+//
+//	error - An error object if watch mode can not be used with the options, otherwise nil.
+func watchInput(w io.Writer, opts *Options, stop <-chan struct{}) error {
+	if opts.fromStdin || len(opts.fromFiles) == 0 || slices.Contains(opts.fromFiles, "-") {
+		return errors.New("--watch requires input files, given with -f")
+	}
+
+	LE := getLE()
+	var state []string
+	ticker := time.NewTicker(WATCH_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		if current := watchState(opts.fromFiles); !slices.Equal(current, state) {
+			state = current
+			var output strings.Builder
+			if opts.outFile == "" {
+				output.WriteString(ANSI_CLEAR)
+				if err, _ := renderInput(&output, opts); err != nil {
+					output.WriteString(err.Error() + LE)
+				}
+			} else if written, err, _ := renderToFile(opts); err != nil {
+				output.WriteString(err.Error() + LE)
+			} else if written {
+				output.WriteString("updated: " + opts.outFile + LE)
+			}
+			if _, err := io.WriteString(w, output.String()); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchState returns a hash of the contents of each file, to compare between polls. The contents are
+// compared rather than the modification time, which may not change when a file is saved twice within the
+// time resolution of the filesystem.
+//
+// Parameters:
+//
+//	files - The paths of the files.
+//
+// Returns:
+//
+//	[]string - The state of each file, or an empty string for files that can not be read.
+func watchState(files []string) []string {
+	state := make([]string, len(files))
+	for i, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+		hash := fnv.New64a()
+		if _, err := io.Copy(hash, f); err == nil {
+			state[i] = strconv.FormatUint(hash.Sum64(), 16)
+		}
+		f.Close()
+	}
+	return state
+}
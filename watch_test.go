@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuilder is a strings.Builder that can be written and read from different goroutines.
+type syncBuilder struct {
+	mutex   sync.Mutex
+	builder strings.Builder
+}
+
+func (b *syncBuilder) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.builder.Write(p)
+}
+
+func (b *syncBuilder) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.builder.String()
+}
+
+// waitFor waits until the output contains the given string, and fails the test if it does not in time.
+func waitFor(t *testing.T, output *syncBuilder, expected string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(output.String(), expected) {
+		if time.Now().After(deadline) {
+			t.Fatalf("watchInput() output does not contain %q:\n%s", expected, output.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchInput(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tree.txt")
+	if err := os.WriteFile(file, []byte("a\n  b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.fromFiles = []string{file}
+	output := &syncBuilder{}
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- watchInput(output, opts, stop)
+	}()
+
+	waitFor(t, output, ANSI_CLEAR+".\n└── a\n    └── b\n")
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("a\n  c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// a save within the time resolution of the filesystem keeps the size and modification time
+	os.Chtimes(file, info.ModTime(), info.ModTime())
+	waitFor(t, output, ANSI_CLEAR+".\n└── a\n    └── c\n")
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, output, ANSI_CLEAR+"error opening file "+file)
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("watchInput() error = %v", err)
+	}
+}
+
+func TestWatchInputErrors(t *testing.T) {
+	tests := []func(opts *Options){
+		func(opts *Options) {},
+		func(opts *Options) { opts.fromStdin = true },
+		func(opts *Options) { opts.fromFiles = []string{"tree.txt", "-"} },
+	}
+
+	for _, setup := range tests {
+		opts := DefaultOptions()
+		setup(opts)
+		err := watchInput(&strings.Builder{}, opts, nil)
+		expected := "--watch requires input files, given with -f"
+		if err == nil || err.Error() != expected {
+			t.Errorf("watchInput() error\n actual = %v\nwant   = %q", err, expected)
+		}
+	}
+}